@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/seaung/nox/pkg/crawler"
@@ -12,6 +15,19 @@ var (
 	crawlerDepth      int
 	crawlerTimeout    int
 	crawlerConcurrent int
+	crawlerEngine     string
+	crawlerScope      string
+	crawlerInclude    []string
+	crawlerExclude    []string
+	crawlerDenyExt    []string
+	crawlerMaxPages   int
+	crawlerClick      bool
+	crawlerAPIOutput  string
+	crawlerSecrets    bool
+	crawlerAllow      []string
+	crawlerSourceMaps bool
+	crawlerSourceDir  string
+	crawlerSeed       bool
 )
 
 var crawlerCmd = &cobra.Command{
@@ -27,15 +43,132 @@ var crawlerCmd = &cobra.Command{
 		c.SetDepth(crawlerDepth)
 		c.SetTimeout(time.Duration(crawlerTimeout) * time.Second)
 		c.SetConcurrent(crawlerConcurrent)
+		if err := c.SetEngine(crawlerEngine); err != nil {
+			fmt.Printf("设置爬取引擎失败: %v\n", err)
+			return
+		}
+
+		c.SetClick(crawlerClick)
+		c.SetSecrets(crawlerSecrets)
+		c.SetSourceMaps(crawlerSourceMaps)
+		c.SetSourceMapDir(crawlerSourceDir)
+		c.SetSeed(crawlerSeed)
+		for _, pattern := range crawlerAllow {
+			if err := c.AddSecretAllowlist(pattern); err != nil {
+				fmt.Printf("设置敏感信息忽略规则失败: %v\n", err)
+				return
+			}
+		}
+
+		// 设置爬取范围
+		c.SetMaxPages(crawlerMaxPages)
+		if err := c.Scope.SetMode(crawlerScope); err != nil {
+			fmt.Printf("设置爬取范围失败: %v\n", err)
+			return
+		}
+		for _, pattern := range crawlerInclude {
+			if err := c.Scope.AddInclude(pattern); err != nil {
+				fmt.Printf("设置爬取范围失败: %v\n", err)
+				return
+			}
+		}
+		for _, pattern := range crawlerExclude {
+			if err := c.Scope.AddExclude(pattern); err != nil {
+				fmt.Printf("设置爬取范围失败: %v\n", err)
+				return
+			}
+		}
+		if cmd.Flags().Changed("deny-ext") {
+			c.Scope.SetDenyExtensions(crawlerDenyExt)
+		}
 
 		// 执行爬虫任务
 		results := c.Crawl()
 
 		// 输出统计信息
-		fmt.Printf("\n总计发现 %d 个URL\n", len(results))
+		outOfScope := 0
+		for _, result := range results {
+			if !result.InScope {
+				outOfScope++
+			}
+		}
+		fmt.Printf("\n总计发现 %d 个URL (范围外 %d 个)\n", len(results), outOfScope)
+
+		// 输出接口与参数
+		endpoints := c.Endpoints()
+		if len(endpoints) > 0 {
+			fmt.Printf("\n发现 %d 个接口:\n", len(endpoints))
+			for _, endpoint := range endpoints {
+				fmt.Printf("  %s\n", formatEndpoint(endpoint))
+			}
+		}
+
+		// 输出robots.txt、.well-known 等文件中值得关注的信息
+		findings := c.Findings()
+		if len(findings) > 0 {
+			fmt.Printf("\n发现 %d 条值得关注的信息:\n", len(findings))
+			for _, finding := range findings {
+				fmt.Printf("  [%s] %s: %s\n", finding.Source, finding.Title, finding.URL)
+			}
+		}
+
+		// 输出敏感信息
+		secrets := c.FoundSecrets()
+		if len(secrets) > 0 {
+			fmt.Printf("\n发现 %d 处敏感信息:\n", len(secrets))
+			for _, secret := range secrets {
+				fmt.Printf("  [%s] %s: %s (%s:%d)\n", secret.Severity, secret.Rule, secret.Value, secret.URL, secret.Line)
+			}
+		}
+
+		// 保存API清单
+		if crawlerAPIOutput != "" {
+			apis := c.APIs()
+			if err := writeAPIInventory(crawlerAPIOutput, apis); err != nil {
+				fmt.Printf("保存API清单失败: %v\n", err)
+				return
+			}
+			fmt.Printf("\nAPI清单已保存到 %s (%d 个接口)\n", crawlerAPIOutput, len(apis))
+		}
 	},
 }
 
+// formatEndpoint 格式化接口，例如 [POST form] http://example.com/login (body.user, body.pass:password)
+func formatEndpoint(endpoint *crawler.Endpoint) string {
+	method := endpoint.Method
+	if method == "" {
+		method = "-"
+	}
+	params := make([]string, 0, len(endpoint.Params))
+	for _, param := range endpoint.Params {
+		name := param.Location + "." + param.Name
+		if param.Type != "" && param.Type != "text" {
+			name += ":" + param.Type
+		}
+		if param.Default != "" {
+			name += "=" + param.Default
+		}
+		params = append(params, name)
+	}
+	line := fmt.Sprintf("[%s %s] %s", method, endpoint.Source, endpoint.URL)
+	if endpoint.Enctype != "" && endpoint.Method == "POST" {
+		line += " " + endpoint.Enctype
+	}
+	return line + " (" + strings.Join(params, ", ") + ")"
+}
+
+// writeAPIInventory 将API清单以JSON格式写入文件
+func writeAPIInventory(path string, apis []*crawler.Endpoint) error {
+	data, err := json.MarshalIndent(apis, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API inventory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write API inventory: %v", err)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(crawlerCmd)
 
@@ -43,4 +176,17 @@ func init() {
 	crawlerCmd.Flags().IntVarP(&crawlerDepth, "depth", "d", 3, "爬取深度 (默认: 3)")
 	crawlerCmd.Flags().IntVarP(&crawlerTimeout, "timeout", "t", 30, "请求超时时间 (秒) (默认: 30)")
 	crawlerCmd.Flags().IntVarP(&crawlerConcurrent, "concurrent", "c", 5, "并发数量 (默认: 5)")
-}
\ No newline at end of file
+	crawlerCmd.Flags().StringVar(&crawlerScope, "scope", crawler.ScopeHost, "爬取范围: host (同主机)、domain (同一可注册域名) 或 any (不限制)")
+	crawlerCmd.Flags().StringArrayVar(&crawlerInclude, "include", nil, "只请求匹配该正则的URL，可重复指定")
+	crawlerCmd.Flags().StringArrayVar(&crawlerExclude, "exclude", nil, "不请求匹配该正则的URL，可重复指定")
+	crawlerCmd.Flags().StringSliceVar(&crawlerDenyExt, "deny-ext", nil, "不请求的文件扩展名，逗号分隔，替换默认的图片、字体等扩展名列表")
+	crawlerCmd.Flags().IntVar(&crawlerMaxPages, "max-pages", 0, "最多请求的页面数量，0表示不限制 (默认: 0)")
+	crawlerCmd.Flags().BoolVar(&crawlerClick, "click", false, "浏览器引擎点击页面中的按钮等元素以触发更多API请求 (可能提交表单，谨慎使用)")
+	crawlerCmd.Flags().StringVar(&crawlerAPIOutput, "api-output", "", "将浏览器捕获的XHR/fetch接口清单以JSON格式保存到文件")
+	crawlerCmd.Flags().BoolVar(&crawlerSecrets, "secrets", true, "检测页面与脚本中的密钥、令牌、内网IP、邮箱与错误堆栈等敏感信息")
+	crawlerCmd.Flags().StringArrayVar(&crawlerAllow, "secret-allow", nil, "忽略匹配该正则的敏感信息，可重复指定")
+	crawlerCmd.Flags().BoolVar(&crawlerSourceMaps, "sourcemaps", true, "获取脚本的source map并分析还原的源代码")
+	crawlerCmd.Flags().StringVar(&crawlerSourceDir, "sourcemap-dir", "", "将source map还原的源代码保存到该目录")
+	crawlerCmd.Flags().BoolVar(&crawlerSeed, "seed", true, "从robots.txt、sitemap.xml与 .well-known 文件收集初始链接")
+	crawlerCmd.Flags().StringVar(&crawlerEngine, "engine", crawler.EngineBrowser, "爬取引擎: http (标准HTTP客户端，无需浏览器) 或 browser (无头浏览器)")
+}
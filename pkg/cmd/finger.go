@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/seaung/nox/pkg/finger"
@@ -9,20 +13,46 @@ import (
 )
 
 var (
-	fingerTimeout int
+	fingerTimeout    int
+	fingerConcurrent int
+	fingerMaxProbes  int
+	fingerRules      []string
+	fingerList       string
+	fingerWorkers    int
+	fingerRate       int
+	fingerJSON       bool
+	fingerAudit      bool
 )
 
 var fingerCmd = &cobra.Command{
 	Use:   "finger [url]",
 	Short: "网站指纹识别模块",
-	Long:  "识别目标网站使用的技术栈和框架",
-	Args:  cobra.ExactArgs(1),
+	Long:  "识别目标网站使用的技术栈和框架，未指定url时从 --list 文件或标准输入批量读取目标",
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		target := args[0]
+		// 加载用户自定义指纹规则
+		customRules := make([]*finger.Rule, 0)
+		for _, dir := range fingerRules {
+			rules, err := finger.LoadRules(dir)
+			if err != nil {
+				fmt.Printf("加载指纹规则失败: %v\n", err)
+				return
+			}
+			customRules = append(customRules, rules...)
+		}
+
+		if len(args) == 0 || fingerList != "" {
+			runBatchFinger(args, customRules)
+			return
+		}
 
 		// 创建指纹识别实例
-		fs := finger.NewFingerScanner(target)
+		fs := finger.NewFingerScanner(args[0])
 		fs.SetTimeout(time.Duration(fingerTimeout) * time.Second)
+		fs.SetConcurrent(fingerConcurrent)
+		fs.SetMaxProbes(fingerMaxProbes)
+		fs.SetAudit(fingerAudit)
+		fs.AddRules(customRules)
 
 		// 执行指纹识别
 		result, err := fs.Scan()
@@ -32,14 +62,117 @@ var fingerCmd = &cobra.Command{
 		}
 
 		// 输出识别结果
+		page := result.Page
 		fmt.Printf("\n目标: %s\n", result.URL)
-		fmt.Printf("发现技术栈: %v\n", result.Technologies)
+		for _, redirect := range page.Redirects {
+			fmt.Printf("跳转: %s [%d]\n", redirect.URL, redirect.StatusCode)
+		}
+		fmt.Printf("最终地址: %s\n", page.FinalURL)
+		fmt.Printf("状态码: %d 标题: %s 长度: %d 耗时: %s\n", page.StatusCode, page.Title, page.ContentLength, page.ResponseTime.Round(time.Millisecond))
+		fmt.Printf("Server: %s SHA-256: %s\n", page.Server, page.BodyHash)
+		if page.Certificate != nil {
+			fmt.Printf("证书: CN=%s 签发者: %s 过期时间: %s SAN: %v\n", page.Certificate.CommonName, page.Certificate.Issuer, page.Certificate.NotAfter.Format("2006-01-02"), page.Certificate.DNSNames)
+		}
+		fmt.Printf("发现技术栈: %d 项\n", len(result.Technologies))
+		for _, tech := range result.Technologies {
+			fmt.Printf("  %s 分类: %v 置信度: %d\n", tech, tech.Categories, tech.Confidence)
+			if tech.CPE != "" {
+				fmt.Printf("    CPE: %s\n", tech.CPE)
+			}
+			for _, evidence := range tech.Evidence {
+				fmt.Printf("    证据: 路径: %s 匹配: %s 片段: %s\n", evidence.Path, evidence.Pattern, evidence.Snippet)
+			}
+		}
+		for _, favicon := range result.Favicons {
+			fmt.Printf("Favicon: %s (mmh3: %d, md5: %s)\n", favicon.URL, favicon.MMH3, favicon.MD5)
+		}
+		if len(result.Findings) > 0 {
+			fmt.Printf("安全问题: %d 项\n", len(result.Findings))
+			for _, finding := range result.Findings {
+				fmt.Printf("  [%s] %s %s\n", finding.Severity, finding.Title, finding.Detail)
+			}
+		}
 	},
 }
 
+// runBatchFinger 批量识别列表文件或标准输入中的目标，结果逐个输出
+func runBatchFinger(args []string, customRules []*finger.Rule) {
+	var reader io.Reader = os.Stdin
+	if fingerList != "" && fingerList != "-" {
+		file, err := os.Open(fingerList)
+		if err != nil {
+			fmt.Printf("读取目标列表失败: %v\n", err)
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	targets, err := finger.LoadTargets(reader)
+	if err != nil {
+		fmt.Printf("读取目标列表失败: %v\n", err)
+		return
+	}
+	targets = append(args, targets...)
+	if len(targets) == 0 {
+		fmt.Println("没有需要识别的目标")
+		return
+	}
+
+	bs := finger.NewBatchScanner(targets)
+	bs.SetTimeout(time.Duration(fingerTimeout) * time.Second)
+	bs.SetConcurrent(fingerWorkers)
+	bs.SetProbes(fingerConcurrent)
+	bs.SetRate(fingerRate)
+	bs.SetMaxProbes(fingerMaxProbes)
+	bs.SetAudit(fingerAudit)
+	bs.AddRules(customRules)
+
+	results, err := bs.Scan()
+	if err != nil {
+		fmt.Printf("指纹识别失败: %v\n", err)
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for result := range results {
+		if fingerJSON {
+			record := struct {
+				Input string `json:"input"`
+				Error string `json:"error,omitempty"`
+				*finger.FingerResult
+			}{Input: result.Input, FingerResult: result.Result}
+			if result.Err != nil {
+				record.Error = result.Err.Error()
+			}
+			encoder.Encode(record)
+			continue
+		}
+
+		if result.Err != nil {
+			fmt.Printf("[-] %s: %v\n", result.Input, result.Err)
+			continue
+		}
+		names := make([]string, 0, len(result.Result.Technologies))
+		for _, tech := range result.Result.Technologies {
+			names = append(names, tech.String())
+		}
+		page := result.Result.Page
+		fmt.Printf("[+] %s [%d] [%s] [%d] [%s] [%s] 安全问题: %d\n", page.FinalURL, page.StatusCode, page.Title, page.ContentLength, page.Server, strings.Join(names, ", "), len(result.Result.Findings))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(fingerCmd)
 
 	// 添加命令行参数
 	fingerCmd.Flags().IntVarP(&fingerTimeout, "timeout", "t", 10, "请求超时时间 (秒) (默认: 10)")
-}
\ No newline at end of file
+	fingerCmd.Flags().IntVarP(&fingerConcurrent, "concurrent", "c", 10, "主动探测并发数量 (默认: 10)")
+	fingerCmd.Flags().IntVar(&fingerMaxProbes, "max-probes", 50, "单个目标最多主动探测的路径数量，0表示不限制 (默认: 50)")
+	fingerCmd.Flags().StringVarP(&fingerList, "list", "l", "", "批量识别的目标列表文件，每行一个URL、主机名或 主机:端口，- 表示标准输入")
+	fingerCmd.Flags().IntVar(&fingerWorkers, "workers", 20, "批量识别时同时处理的目标数量 (默认: 20)")
+	fingerCmd.Flags().IntVar(&fingerRate, "rate", 0, "批量识别时每个主机每秒最大请求数，0表示不限制 (默认: 0)")
+	fingerCmd.Flags().BoolVar(&fingerJSON, "json", false, "批量识别时以JSON行格式输出结果")
+	fingerCmd.Flags().BoolVar(&fingerAudit, "audit", false, "审计安全响应头、CORS与Cookie属性")
+	fingerCmd.Flags().StringArrayVar(&fingerRules, "rules", nil, "自定义指纹规则目录或文件 (YAML/JSON)，可重复指定")
+}
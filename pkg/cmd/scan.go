@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/seaung/nox/pkg/port"
+	"github.com/seaung/nox/pkg/waf"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,7 @@ var (
 	scanPorts     string
 	scanTimeout   int
 	scanConcurrent int
+	scanSkipCDN    bool
 )
 
 var scanCmd = &cobra.Command{
@@ -24,6 +26,19 @@ var scanCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		target := args[0]
 
+		// 目标位于CDN之后时扫描到的是CDN节点而非源站
+		if scanSkipCDN {
+			cdn, err := waf.DefaultCDNRanges().CheckHost(target)
+			if err != nil {
+				fmt.Printf("CDN检测失败: %v\n", err)
+				return
+			}
+			if cdn.Fronted {
+				fmt.Printf("目标 %s 位于CDN之后 (%s)，跳过端口扫描\n", target, strings.Join(cdn.Providers, ", "))
+				return
+			}
+		}
+
 		// 创建端口扫描实例
 		ps := port.NewPortScanner(target, port.TCP_CONNECT)
 
@@ -70,4 +85,5 @@ func init() {
 	scanCmd.Flags().StringVarP(&scanPorts, "ports", "p", "1-1000", "端口范围 (例如: 80,443 或 1-1000)")
 	scanCmd.Flags().IntVarP(&scanTimeout, "timeout", "t", 2, "单个端口扫描超时时间 (秒) (默认: 2)")
 	scanCmd.Flags().IntVarP(&scanConcurrent, "concurrent", "c", 100, "并发数量 (默认: 100)")
+	scanCmd.Flags().BoolVar(&scanSkipCDN, "skip-cdn", false, "目标IP均属于CDN网段时跳过扫描")
 }
\ No newline at end of file
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/seaung/nox/pkg/subdomain"
@@ -9,9 +12,27 @@ import (
 )
 
 var (
-	subdomainWordlist string
-	subdomainTimeout  int
-	subdomainConcurrent int
+	subdomainWordlist      string
+	subdomainTimeout       int
+	subdomainConcurrent    int
+	subdomainWildcardTests int
+	subdomainResolvers     string
+	subdomainRetries       int
+	subdomainRate          int
+	subdomainRecords       bool
+	subdomainTakeover      bool
+	subdomainSignatures    string
+	subdomainZoneWalk      bool
+	subdomainRecursive     bool
+	subdomainMaxDepth      int
+	subdomainRecursiveList string
+	subdomainPermute       bool
+	subdomainPermuteBudget int
+	subdomainSources       []string
+	subdomainReverse       bool
+	subdomainReversePrefix int
+	subdomainASNDatabase   string
+	subdomainOutput        string
 )
 
 var subdomainCmd = &cobra.Command{
@@ -24,9 +45,76 @@ var subdomainCmd = &cobra.Command{
 
 		// 创建子域名扫描实例
 		ss := subdomain.NewSubdomainScanner(target)
-		ss.SetWordlist([]string{subdomainWordlist})
+		wordlist, err := subdomain.LoadWordlist(subdomainWordlist)
+		if err != nil {
+			fmt.Printf("加载子域名字典失败: %v\n", err)
+			return
+		}
+		ss.SetWordlist(wordlist)
 		ss.SetTimeout(time.Duration(subdomainTimeout) * time.Second)
 		ss.SetConcurrent(subdomainConcurrent)
+		ss.SetWildcardTests(subdomainWildcardTests)
+		ss.SetRetries(subdomainRetries)
+		ss.SetRate(subdomainRate)
+		ss.SetRecords(subdomainRecords)
+		ss.SetTakeover(subdomainTakeover)
+		ss.SetZoneWalk(subdomainZoneWalk)
+		ss.SetRecursive(subdomainRecursive, subdomainMaxDepth)
+		ss.SetPermute(subdomainPermute, subdomainPermuteBudget)
+		if err := ss.SetReverse(subdomainReverse, subdomainReversePrefix); err != nil {
+			fmt.Printf("设置反向解析失败: %v\n", err)
+			return
+		}
+
+		// 加载离线ASN数据库
+		var asnDatabase *subdomain.ASNDatabase
+		if subdomainASNDatabase != "" {
+			asnDatabase, err = subdomain.LoadASNDatabase(subdomainASNDatabase)
+			if err != nil {
+				fmt.Printf("加载ASN数据库失败: %v\n", err)
+				return
+			}
+		}
+
+		// 添加被动来源
+		for _, spec := range subdomainSources {
+			source, err := subdomain.ParseSource(spec)
+			if err != nil {
+				fmt.Printf("解析被动来源失败: %v\n", err)
+				return
+			}
+			ss.AddSource(source)
+		}
+
+		// 加载递归爆破使用的字典
+		if subdomainRecursiveList != "" {
+			recursiveWordlist, err := subdomain.LoadWordlist(subdomainRecursiveList)
+			if err != nil {
+				fmt.Printf("加载递归爆破字典失败: %v\n", err)
+				return
+			}
+			ss.SetRecursiveWordlist(recursiveWordlist)
+		}
+
+		// 加载自定义DNS服务器列表
+		if subdomainResolvers != "" {
+			servers, err := subdomain.LoadResolvers(subdomainResolvers)
+			if err != nil {
+				fmt.Printf("加载DNS服务器列表失败: %v\n", err)
+				return
+			}
+			ss.SetResolvers(servers)
+		}
+
+		// 加载自定义子域名接管特征
+		if subdomainSignatures != "" {
+			signatures, err := subdomain.LoadTakeoverSignatures(subdomainSignatures)
+			if err != nil {
+				fmt.Printf("加载子域名接管特征失败: %v\n", err)
+				return
+			}
+			ss.SetTakeoverSignatures(signatures)
+		}
 
 		// 执行子域名扫描
 		results := ss.Scan()
@@ -34,12 +122,75 @@ var subdomainCmd = &cobra.Command{
 		// 输出扫描结果
 		fmt.Printf("\n目标域名: %s\n", target)
 		for _, result := range results {
-			fmt.Printf("子域名: %s (IP: %v)\n", result.Subdomain, result.IPList)
+			fmt.Printf("子域名: %s (IP: %v, 来源: %s)\n", result.Subdomain, result.IPList, result.Source)
+			if subdomainRecords {
+				printRecords(result)
+			}
+			for _, finding := range result.Takeovers {
+				service := finding.Service
+				if service == "" {
+					service = "未知"
+				}
+				fmt.Printf("  [%s] 子域名接管风险: %s -> %s (服务: %s, 依据: %s)\n", finding.Severity, finding.Subdomain, finding.Target, service, finding.Evidence)
+			}
 		}
 		fmt.Printf("\n总计发现 %d 个子域名\n", len(results))
+
+		// 按ASN网段分组输出
+		if asnDatabase != nil {
+			fmt.Printf("\nASN网段分布:\n")
+			for _, group := range subdomain.GroupNetblocks(results, asnDatabase) {
+				if group.ASN == "" {
+					fmt.Printf("未收录网段: %v\n", group.IPs)
+					continue
+				}
+				fmt.Printf("AS%s %s (%s): %v\n", group.ASN, group.Name, group.Prefix, group.IPs)
+			}
+		}
+
+		// 保存JSON格式的扫描结果，可作为后续扫描的nox被动来源
+		if subdomainOutput != "" {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err == nil {
+				err = os.WriteFile(subdomainOutput, data, 0644)
+			}
+			if err != nil {
+				fmt.Printf("保存扫描结果失败: %v\n", err)
+				return
+			}
+			fmt.Printf("扫描结果已保存到 %s\n", subdomainOutput)
+		}
 	},
 }
 
+// printRecords 输出子域名的完整DNS记录
+func printRecords(result subdomain.SubdomainResult) {
+	if len(result.CNAMEs) > 0 {
+		fmt.Printf("  CNAME: %s\n", strings.Join(result.CNAMEs, " -> "))
+	}
+	for _, mx := range result.MX {
+		fmt.Printf("  MX: %s (优先级: %d)\n", mx.Host, mx.Preference)
+	}
+	for _, ns := range result.NS {
+		fmt.Printf("  NS: %s\n", ns)
+	}
+	for _, txt := range result.TXT {
+		fmt.Printf("  TXT: %s\n", txt)
+	}
+	if result.SPF != "" {
+		fmt.Printf("  SPF: %s\n", result.SPF)
+	}
+	if result.DMARC != "" {
+		fmt.Printf("  DMARC: %s\n", result.DMARC)
+	}
+	for _, srv := range result.SRV {
+		fmt.Printf("  SRV: %s -> %s:%d (优先级: %d, 权重: %d)\n", srv.Service, srv.Target, srv.Port, srv.Priority, srv.Weight)
+	}
+	for _, caa := range result.CAA {
+		fmt.Printf("  CAA: %d %s %q\n", caa.Flag, caa.Tag, caa.Value)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(subdomainCmd)
 
@@ -47,4 +198,22 @@ func init() {
 	subdomainCmd.Flags().StringVarP(&subdomainWordlist, "wordlist", "w", "wordlist/domain.nox", "子域名字典文件路径")
 	subdomainCmd.Flags().IntVarP(&subdomainTimeout, "timeout", "t", 5, "单个子域名解析超时时间 (秒) (默认: 5)")
 	subdomainCmd.Flags().IntVarP(&subdomainConcurrent, "concurrent", "c", 50, "并发数量 (默认: 50)")
-}
\ No newline at end of file
+	subdomainCmd.Flags().IntVar(&subdomainWildcardTests, "wildcard-tests", 5, "泛解析检测时每一层级探测的随机子域名数量，0表示不检测 (默认: 5)")
+	subdomainCmd.Flags().StringVarP(&subdomainResolvers, "resolvers", "r", "", "DNS服务器列表文件路径，每行一个 (默认使用系统DNS)")
+	subdomainCmd.Flags().IntVar(&subdomainRetries, "retries", 2, "每个DNS服务器的重试次数 (默认: 2)")
+	subdomainCmd.Flags().IntVar(&subdomainRate, "rate", 0, "每个DNS服务器每秒最大查询次数，0表示不限制 (默认: 0)")
+	subdomainCmd.Flags().BoolVar(&subdomainRecords, "records", false, "收集CNAME/MX/NS/TXT/SRV/CAA等完整DNS记录")
+	subdomainCmd.Flags().BoolVar(&subdomainTakeover, "takeover", false, "沿CNAME链检测悬挂记录与子域名接管风险")
+	subdomainCmd.Flags().StringVar(&subdomainSignatures, "takeover-signatures", "", "子域名接管特征文件路径 (JSON，默认使用内置特征)")
+	subdomainCmd.Flags().BoolVar(&subdomainZoneWalk, "zone", false, "爆破前尝试区域传送(AXFR)与NSEC遍历")
+	subdomainCmd.Flags().BoolVar(&subdomainRecursive, "recursive", false, "以已发现的子域名为基础递归爆破")
+	subdomainCmd.Flags().IntVar(&subdomainMaxDepth, "max-depth", 3, "递归爆破时相对目标域名的最大层数 (默认: 3)")
+	subdomainCmd.Flags().StringVar(&subdomainRecursiveList, "recursive-wordlist", "", "递归爆破使用的字典文件路径 (默认使用--wordlist)")
+	subdomainCmd.Flags().BoolVar(&subdomainPermute, "permute", false, "基于已发现的子域名生成置换变体继续扫描")
+	subdomainCmd.Flags().IntVar(&subdomainPermuteBudget, "permute-budget", 10000, "置换阶段最多解析的候选数量 (默认: 10000)")
+	subdomainCmd.Flags().BoolVar(&subdomainReverse, "reverse", false, "对已发现IP的邻近网段做反向解析(PTR)")
+	subdomainCmd.Flags().IntVar(&subdomainReversePrefix, "reverse-prefix", 24, "反向解析邻近网段的前缀长度，取值16到32 (默认: 24)")
+	subdomainCmd.Flags().StringVar(&subdomainASNDatabase, "asn-db", "", "离线ASN数据库文件路径，用于按网段分组IP")
+	subdomainCmd.Flags().StringArrayVar(&subdomainSources, "source", nil, "被动来源，可重复指定 (ct:文件, dataset:文件, nox:文件, crtsh-file:文件, crtsh[:接口地址])")
+	subdomainCmd.Flags().StringVarP(&subdomainOutput, "output", "o", "", "以JSON格式保存扫描结果的文件路径")
+}
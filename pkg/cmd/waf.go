@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seaung/nox/pkg/waf"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wafTimeout    int
+	wafSignatures string
+	wafCDNRanges  string
+)
+
+var wafCmd = &cobra.Command{
+	Use:   "waf [url]",
+	Short: "WAF与CDN检测模块",
+	Long:  "发送正常请求与恶意请求并比较响应，识别目标前置的WAF厂商以及CDN",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := args[0]
+
+		// 创建WAF检测实例
+		wd := waf.NewWAFDetector(target)
+		wd.SetTimeout(time.Duration(wafTimeout) * time.Second)
+
+		// 加载自定义厂商特征
+		if wafSignatures != "" {
+			signatures, err := waf.LoadSignatures(wafSignatures)
+			if err != nil {
+				fmt.Printf("加载WAF特征失败: %v\n", err)
+				return
+			}
+			wd.SetSignatures(signatures)
+		}
+
+		// 加载自定义CDN网段，与内置网段合并
+		if wafCDNRanges != "" {
+			ranges, err := waf.LoadCDNRanges(wafCDNRanges)
+			if err != nil {
+				fmt.Printf("加载CDN网段失败: %v\n", err)
+				return
+			}
+			wd.CDNRanges.Merge(ranges)
+		}
+
+		// 执行检测
+		result, err := wd.Detect()
+		if err != nil {
+			fmt.Printf("WAF检测失败: %v\n", err)
+			return
+		}
+
+		// 输出检测结果
+		fmt.Printf("\n目标: %s\n", result.URL)
+		if result.Detected {
+			fmt.Printf("检测到WAF: %s\n", result.Vendor)
+		} else {
+			fmt.Println("未检测到WAF")
+		}
+		for _, vendor := range result.Vendors {
+			fmt.Printf("  %s (%s): %s\n", vendor.Vendor, vendor.Type, strings.Join(vendor.Evidence, "; "))
+		}
+		for _, probe := range result.Probes {
+			if probe.Blocked {
+				fmt.Printf("  探测 %s 被拦截: %s\n", probe.Name, probe.Reason)
+			} else if probe.Error != "" {
+				fmt.Printf("  探测 %s 失败: %s\n", probe.Name, probe.Error)
+			}
+		}
+		if result.CDN != nil && len(result.CDN.Providers) > 0 {
+			fmt.Printf("CDN: %s (IP: %v, 全部位于CDN: %v)\n", strings.Join(result.CDN.Providers, ", "), result.CDN.IPs, result.CDN.Fronted)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(wafCmd)
+
+	// 添加命令行参数
+	wafCmd.Flags().IntVarP(&wafTimeout, "timeout", "t", 10, "请求超时时间 (秒) (默认: 10)")
+	wafCmd.Flags().StringVar(&wafSignatures, "signatures", "", "自定义WAF/CDN特征文件 (JSON)，替换内置特征")
+	wafCmd.Flags().StringVar(&wafCDNRanges, "cdn-ranges", "", "自定义CDN网段文件，每行为 网段 服务商名称")
+}
@@ -0,0 +1,200 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// clickSelector 点击模式下尝试点击的元素
+const clickSelector = `button, [onclick], [role="button"], a[href^="javascript:"], input[type="button"]`
+
+// requestIdle 页面加载后等待网络空闲的时间，以便捕获异步发出的XHR/fetch请求
+const requestIdle = 500 * time.Millisecond
+
+// BrowserEngine 使用无头浏览器的爬取引擎，可以获取JavaScript渲染后的页面
+// 页面加载期间发出的XHR/fetch请求会作为API请求记录
+type BrowserEngine struct {
+	Timeout   time.Duration // 单个页面的加载超时时间
+	Click     bool          // 是否点击页面元素以触发更多请求
+	MaxClicks int           // 每个页面最多点击的元素数量
+
+	http    *HTTPEngine  // 脚本文件无需渲染，直接使用HTTP引擎请求
+	browser *rod.Browser // rod浏览器实例，首次请求时启动
+	once    sync.Once    // 保证浏览器只启动一次
+	err     error        // 启动浏览器时的错误
+}
+
+// NewBrowserEngine 创建一个新的浏览器爬取引擎
+func NewBrowserEngine(timeout time.Duration) *BrowserEngine {
+	return &BrowserEngine{
+		Timeout:   timeout,
+		MaxClicks: 20,
+		http:      NewHTTPEngine(timeout),
+	}
+}
+
+// launch 启动浏览器
+func (e *BrowserEngine) launch() error {
+	e.once.Do(func() {
+		controlURL, err := launcher.New().
+			Headless(true).
+			Leakless(true).
+			Launch()
+		if err != nil {
+			e.err = fmt.Errorf("failed to launch browser: %v", err)
+			return
+		}
+
+		browser := rod.New().ControlURL(controlURL)
+		if err := browser.Connect(); err != nil {
+			e.err = fmt.Errorf("failed to connect browser: %v", err)
+			return
+		}
+		e.browser = browser
+	})
+	return e.err
+}
+
+// Fetch 在浏览器中加载页面，解析渲染后的HTML，并记录加载期间的XHR/fetch请求
+func (e *BrowserEngine) Fetch(pageURL string) (*Page, error) {
+	if isJavaScript("", pageURL) {
+		return e.http.Fetch(pageURL)
+	}
+	if err := e.launch(); err != nil {
+		return nil, err
+	}
+
+	page, err := e.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page: %v", err)
+	}
+	defer page.Close()
+	page = page.Timeout(e.Timeout)
+
+	// 在导航之前开始监听网络请求，并关闭页面弹出的对话框以免阻塞点击
+	requests := make([]APIRequest, 0)
+	mu := sync.Mutex{}
+	listener, stop := page.WithCancel()
+	defer stop()
+	go listener.EachEvent(func(ev *proto.NetworkRequestWillBeSent) {
+		if ev.Type != proto.NetworkResourceTypeXHR && ev.Type != proto.NetworkResourceTypeFetch {
+			return
+		}
+		mu.Lock()
+		requests = append(requests, newAPIRequest(ev.Request, ev.Type))
+		mu.Unlock()
+	}, func(ev *proto.PageJavascriptDialogOpening) {
+		_ = proto.PageHandleJavaScriptDialog{Accept: false}.Call(listener)
+	})()
+
+	idle := page.WaitRequestIdle(requestIdle, nil, nil, nil)
+	if err := page.Navigate(pageURL); err != nil {
+		return nil, fmt.Errorf("failed to open page: %v", err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return nil, fmt.Errorf("failed to load page: %v", err)
+	}
+	idle()
+
+	info, err := page.Info()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page info: %v", err)
+	}
+	content, err := page.HTML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page content: %v", err)
+	}
+
+	result := &Page{
+		URL:         info.URL,
+		ContentType: "text/html",
+		Body:        []byte(content),
+		Links:       make([]Link, 0),
+	}
+	if info.URL != pageURL {
+		result.Links = append(result.Links, Link{URL: info.URL, Kind: LinkRedirect})
+	}
+	parseHTML(result, strings.NewReader(content))
+
+	if e.Click {
+		if target := e.clickElements(page, info.URL); target != "" {
+			result.Links = append(result.Links, Link{URL: target, Kind: LinkClick})
+		}
+	}
+
+	mu.Lock()
+	result.Requests = append(result.Requests, requests...)
+	mu.Unlock()
+	for _, request := range result.Requests {
+		result.Links = append(result.Links, Link{URL: request.URL, Kind: LinkXHR})
+	}
+	return result, nil
+}
+
+// clickElements 依次点击页面中的可点击元素并等待触发的请求完成
+// 点击导致页面跳转时停止点击，并返回跳转后的地址
+func (e *BrowserEngine) clickElements(page *rod.Page, pageURL string) string {
+	elements, err := page.Elements(clickSelector)
+	if err != nil {
+		return ""
+	}
+
+	for i, element := range elements {
+		if i >= e.MaxClicks {
+			break
+		}
+		if visible, err := element.Visible(); err != nil || !visible {
+			continue
+		}
+
+		idle := page.WaitRequestIdle(requestIdle, nil, nil, nil)
+		if err := element.Timeout(time.Second).Click(proto.InputMouseButtonLeft, 1); err != nil {
+			continue
+		}
+		idle()
+
+		if info, err := page.Info(); err == nil && info.URL != pageURL {
+			return info.URL
+		}
+	}
+	return ""
+}
+
+// newAPIRequest 将浏览器的网络请求转换为API请求
+func newAPIRequest(request *proto.NetworkRequest, resourceType proto.NetworkResourceType) APIRequest {
+	headers := make(map[string]string, len(request.Headers))
+	for name, value := range request.Headers {
+		headers[name] = value.Str()
+	}
+
+	// 较长的请求体只出现在postDataEntries中
+	body := request.PostData
+	if body == "" {
+		for _, entry := range request.PostDataEntries {
+			body += string(entry.Bytes)
+		}
+	}
+
+	return APIRequest{
+		Method:  request.Method,
+		URL:     request.URL,
+		Headers: headers,
+		Body:    body,
+		Type:    strings.ToLower(string(resourceType)),
+	}
+}
+
+// Close 关闭浏览器
+func (e *BrowserEngine) Close() error {
+	e.http.Close()
+	if e.browser == nil {
+		return nil
+	}
+	return e.browser.Close()
+}
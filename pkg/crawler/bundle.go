@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// routeKeys 路由定义中表示路径的键名，例如Vue Router的 {path: "/user/:id"}
+// 以及编译后的React Router jsx(Route, {path: "/login"})
+var routeKeys = map[string]bool{
+	"path":     true,
+	"redirect": true,
+	"to":       true,
+}
+
+// chunkHashPattern webpack分块文件名中的内容哈希
+var chunkHashPattern = regexp.MustCompile(`^[0-9a-f]{6,32}$`)
+
+// publicPathPattern webpack运行时中的publicPath，例如 __webpack_require__.p = "/static/"
+var publicPathPattern = regexp.MustCompile(`\.p\s*=\s*["']([^"']*)["']`)
+
+// jsToken JavaScript词法单元
+type jsToken struct {
+	Type js.TokenType
+	Text string
+}
+
+// tokenizeJS 将JavaScript代码切分为词法单元，忽略空白、换行与注释
+func tokenizeJS(jsCode string) []jsToken {
+	tokens := make([]jsToken, 0)
+	lexer := js.NewLexer(parse.NewInput(strings.NewReader(jsCode)))
+	for {
+		tt, text := lexer.Next()
+		switch tt {
+		case js.ErrorToken:
+			return tokens
+		case js.WhitespaceToken, js.LineTerminatorToken, js.CommentToken, js.CommentLineTerminatorToken:
+			continue
+		}
+		tokens = append(tokens, jsToken{Type: tt, Text: string(text)})
+	}
+}
+
+// extractRoutesFromJS 从路由定义中提取前端路由路径
+func extractRoutesFromJS(tokens []jsToken) []string {
+	routes := make([]string, 0)
+	seen := make(map[string]bool)
+	for i := 1; i+2 < len(tokens); i++ {
+		if tokens[i-1].Text != "{" && tokens[i-1].Text != "," {
+			continue
+		}
+		key := strings.Trim(tokens[i].Text, "\"'")
+		if !routeKeys[key] || tokens[i+1].Text != ":" || tokens[i+2].Type != js.StringToken {
+			continue
+		}
+		route := strings.Trim(tokens[i+2].Text, "\"'")
+		if strings.HasPrefix(route, "/") && !strings.HasPrefix(route, "//") && !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// extractChunksFromJS 从webpack运行时中还原按需加载的分块文件地址
+// 例如 "static/js/" + e + "." + {12: "a1b2c3d4"}[e] + ".chunk.js"
+func extractChunksFromJS(tokens []jsToken, jsCode string) []string {
+	// publicPath为空时分块位于站点根目录
+	publicPath := "/"
+	if match := publicPathPattern.FindStringSubmatch(jsCode); match != nil && match[1] != "" {
+		publicPath = match[1]
+	}
+
+	chunks := make([]string, 0)
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Text != "{" {
+			continue
+		}
+		hashes, end := parseChunkMap(tokens, i)
+		// 分块映射后面必须紧跟 [变量] + "后缀.js"
+		if len(hashes) == 0 || end+5 >= len(tokens) {
+			continue
+		}
+		if tokens[end+1].Text != "[" || tokens[end+3].Text != "]" || tokens[end+4].Text != "+" || tokens[end+5].Type != js.StringToken {
+			continue
+		}
+		suffix := strings.Trim(tokens[end+5].Text, "\"'")
+		if !strings.HasSuffix(suffix, ".js") {
+			continue
+		}
+
+		// 向前查找以 / 结尾的目录前缀，以及哈希前的分隔符
+		prefix, separator := "", "."
+		if i >= 2 && tokens[i-1].Text == "+" && tokens[i-2].Type == js.StringToken {
+			separator = strings.Trim(tokens[i-2].Text, "\"'")
+		}
+		for j := i - 1; j >= 0 && j >= i-30; j-- {
+			if tokens[j].Type == js.StringToken && strings.HasSuffix(strings.Trim(tokens[j].Text, "\"'"), "/") {
+				prefix = strings.Trim(tokens[j].Text, "\"'")
+				break
+			}
+		}
+
+		for _, entry := range hashes {
+			chunks = append(chunks, publicPath+prefix+entry[0]+separator+entry[1]+suffix)
+		}
+		i = end
+	}
+	return chunks
+}
+
+// parseChunkMap 解析从start处开始的 {id: "hash", ...} 对象，返回分块编号与哈希以及右括号的位置
+func parseChunkMap(tokens []jsToken, start int) ([][2]string, int) {
+	hashes := make([][2]string, 0)
+	i := start + 1
+	for i+2 < len(tokens) {
+		key, colon, value := tokens[i], tokens[i+1], tokens[i+2]
+		if colon.Text != ":" || value.Type != js.StringToken {
+			return nil, 0
+		}
+		hash := strings.Trim(value.Text, "\"'")
+		if !chunkHashPattern.MatchString(hash) {
+			return nil, 0
+		}
+		hashes = append(hashes, [2]string{strings.Trim(key.Text, "\"'"), hash})
+
+		i += 3
+		if i >= len(tokens) {
+			break
+		}
+		switch tokens[i].Text {
+		case "}":
+			return hashes, i
+		case ",":
+			i++
+		default:
+			return nil, 0
+		}
+	}
+	return nil, 0
+}
+
+// analyzeBundle 分析JavaScript代码，返回其中的前端路由、webpack分块与其他URL链接
+// 路由同样是字符串，因此先记录路由，使其以更具体的来源出现在结果中
+func analyzeBundle(jsCode string) []Link {
+	links := make([]Link, 0)
+	tokens := tokenizeJS(jsCode)
+	for _, route := range extractRoutesFromJS(tokens) {
+		links = append(links, Link{URL: route, Kind: LinkRoute})
+	}
+	for _, chunk := range extractChunksFromJS(tokens, jsCode) {
+		links = append(links, Link{URL: chunk, Kind: LinkChunk})
+	}
+	for _, raw := range extractURLsFromJS(jsCode) {
+		links = append(links, Link{URL: raw, Kind: LinkJS})
+	}
+	return links
+}
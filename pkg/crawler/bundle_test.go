@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractRoutesFromJS(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "vue router",
+			code: `const routes = [{path: "/login", component: Login}, {path: '/user/:id', redirect: "/user/:id/profile"}, {path: "*", component: NotFound}]`,
+			want: []string{"/login", "/user/:id", "/user/:id/profile"},
+		},
+		{
+			name: "compiled react router",
+			code: `jsx(Route, {path: "/admin", element: a}), jsx(Navigate, {"to": "/dashboard"}), jsx(Route, {path: "/admin"})`,
+			want: []string{"/admin", "/dashboard"},
+		},
+		{
+			name: "not a route definition",
+			code: `var path = "/etc/hosts"; obj.path = "/tmp"; f({url: "/api/list"}); x ? {path: cond} : 0; {path: "//cdn.test/a.js"}`,
+			want: []string{},
+		},
+		{
+			name: "relative and comments",
+			code: `// {path: "/commented"}
+			{path: "relative", to: "/abs"}`,
+			want: []string{"/abs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractRoutesFromJS(tokenizeJS(tt.code)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractRoutesFromJS() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractChunksFromJS(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "create react app",
+			code: `__webpack_require__.p = "/static/"; s.src = __webpack_require__.p + "static/js/" + e + "." + {12: "a1b2c3d4", 34: "0f9e8d7c"}[e] + ".chunk.js"`,
+			want: []string{"/static/static/js/12.a1b2c3d4.chunk.js", "/static/static/js/34.0f9e8d7c.chunk.js"},
+		},
+		{
+			name: "default public path and separator",
+			code: `return r.p + "js/" + ({}[e] || e) + "-" + {"chunk-1f2e": "deadbeef"}[e] + ".js"`,
+			want: []string{"/js/chunk-1f2e-deadbeef.js"},
+		},
+		{
+			name: "quoted keys without prefix",
+			code: `n.p = ""; u = e + "." + {"7": "abcdef12"}[e] + ".js"`,
+			want: []string{"/7.abcdef12.js"},
+		},
+		{
+			name: "not a chunk map",
+			code: `var a = {1: "not-a-hash"}[e] + ".js"; var b = {1: "abcdef12"}[e] + ".css"; var c = {1: "abcdef12", x: y}[e] + ".js"`,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractChunksFromJS(tokenizeJS(tt.code), tt.code); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractChunksFromJS() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,14 @@ package crawler
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/launcher"
 	"github.com/seaung/nox/pkg/utils"
 	"github.com/tdewolff/parse/v2"
 	"github.com/tdewolff/parse/v2/js"
@@ -16,31 +17,59 @@ import (
 
 // Crawler Web爬虫结构体
 type Crawler struct {
-	Target     string         // 目标URL
-	Depth      int           // 爬取深度
-	Timeout    time.Duration // 超时时间
-	Concurrent int          // 并发数量
-	Logger     *utils.Logger // 日志记录器
-
-	visited sync.Map        // 已访问的URL
-	browser *rod.Browser    // rod浏览器实例
+	Target          string           // 目标URL
+	Depth           int              // 爬取深度
+	Timeout         time.Duration    // 超时时间
+	Concurrent      int              // 并发数量
+	Engine          string           // 爬取引擎 http/browser
+	MaxPages        int              // 最多请求的页面数量，0表示不限制
+	Click           bool             // 浏览器引擎是否点击页面元素以触发更多API请求
+	Secrets         bool             // 是否检测页面与脚本中的敏感信息
+	SecretRules     []*SecretRule    // 敏感信息检测规则
+	SecretAllowlist []*regexp.Regexp // 匹配其中之一的值不作为敏感信息
+	SourceMaps      bool             // 是否获取脚本的source map并分析还原的源代码
+	SourceMapDir    string           // source map还原的源代码输出目录，为空时不写入磁盘
+	Seed            bool             // 是否从robots.txt、sitemap与 .well-known 文件收集初始链接
+	Scope           *Scope           // 爬取范围规则
+	Logger          *utils.Logger    // 日志记录器
+
+	visited   sync.Map       // 已访问的URL
+	patterns  sync.Map       // 已请求的URL模式
+	pages     int64          // 已请求的页面数量
+	engine    Engine         // 爬取引擎实例
+	endpoints *endpointSet   // 发现的接口
+	secrets   *secretScanner // 敏感信息扫描器
+	client    *http.Client   // 获取source map与种子文件使用的HTTP客户端
+	findings  []Finding      // 值得关注的信息
+	mu        sync.Mutex     // 保护findings
 }
 
 // CrawlResult 爬取结果结构体
 type CrawlResult struct {
-	URL      string   // 发现的URL
-	Depth    int     // URL的深度
+	URL       string // 发现的URL
+	Depth     int    // URL的深度
 	ParentURL string // 父URL
+	Kind      string // 链接来源，例如a、form、script、js
+	InScope   bool   // 是否在爬取范围内，范围外的URL只记录不请求
+	Reason    string // 不在爬取范围内的原因
 }
 
 // NewCrawler 创建一个新的爬虫实例
 func NewCrawler(target string) *Crawler {
+	allowlist, _ := compileAllowlist(defaultSecretAllowlist)
 	return &Crawler{
-		Target:     target,
-		Depth:      3,
-		Timeout:    time.Second * 30,
-		Concurrent: 5,
-		Logger:     utils.New(),
+		Target:          target,
+		Depth:           3,
+		Timeout:         time.Second * 30,
+		Concurrent:      5,
+		Engine:          EngineBrowser,
+		Scope:           NewScope(target),
+		Secrets:         true,
+		SecretRules:     DefaultSecretRules(),
+		SecretAllowlist: allowlist,
+		SourceMaps:      true,
+		Seed:            true,
+		Logger:          utils.New(),
 	}
 }
 
@@ -59,8 +88,92 @@ func (c *Crawler) SetConcurrent(concurrent int) {
 	c.Concurrent = concurrent
 }
 
+// SetEngine 设置爬取引擎 http/browser
+func (c *Crawler) SetEngine(engine string) error {
+	switch engine {
+	case EngineHTTP, EngineBrowser:
+		c.Engine = engine
+		return nil
+	}
+	return fmt.Errorf("unknown crawler engine %q", engine)
+}
+
+// SetMaxPages 设置最多请求的页面数量
+func (c *Crawler) SetMaxPages(maxPages int) {
+	c.MaxPages = maxPages
+}
+
+// SetClick 设置浏览器引擎是否点击页面元素
+func (c *Crawler) SetClick(click bool) {
+	c.Click = click
+}
+
+// SetSecrets 设置是否检测敏感信息
+func (c *Crawler) SetSecrets(secrets bool) {
+	c.Secrets = secrets
+}
+
+// SetSeed 设置是否从robots.txt、sitemap与 .well-known 文件收集初始链接
+func (c *Crawler) SetSeed(seed bool) {
+	c.Seed = seed
+}
+
+// Findings 返回最近一次爬取中发现的值得关注的信息，例如robots.txt中禁止爬取的路径
+func (c *Crawler) Findings() []Finding {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Finding(nil), c.findings...)
+}
+
+// SetSourceMaps 设置是否获取脚本的source map并分析还原的源代码
+func (c *Crawler) SetSourceMaps(sourceMaps bool) {
+	c.SourceMaps = sourceMaps
+}
+
+// SetSourceMapDir 设置source map还原的源代码输出目录
+func (c *Crawler) SetSourceMapDir(dir string) {
+	c.SourceMapDir = dir
+}
+
+// AddSecretAllowlist 添加敏感信息忽略规则，匹配该正则的值不会被记录
+func (c *Crawler) AddSecretAllowlist(pattern string) error {
+	allowlist, err := compileAllowlist([]string{pattern})
+	if err != nil {
+		return err
+	}
+	c.SecretAllowlist = append(c.SecretAllowlist, allowlist...)
+	return nil
+}
+
+// FoundSecrets 返回最近一次爬取中发现的敏感信息
+func (c *Crawler) FoundSecrets() []Secret {
+	if c.secrets == nil {
+		return nil
+	}
+	return c.secrets.list()
+}
+
+// APIs 返回最近一次爬取中浏览器捕获的XHR/fetch接口清单
+func (c *Crawler) APIs() []*Endpoint {
+	apis := make([]*Endpoint, 0)
+	for _, endpoint := range c.Endpoints() {
+		if endpoint.Source == SourceXHR {
+			apis = append(apis, endpoint)
+		}
+	}
+	return apis
+}
+
+// Endpoints 返回最近一次爬取中发现的表单、查询参数、JavaScript键名与XHR/fetch接口
+func (c *Crawler) Endpoints() []*Endpoint {
+	if c.endpoints == nil {
+		return nil
+	}
+	return c.endpoints.list()
+}
+
 // extractURLsFromJS 从JavaScript代码中提取URL
-func (c *Crawler) extractURLsFromJS(jsCode string) []string {
+func extractURLsFromJS(jsCode string) []string {
 	urls := make([]string, 0)
 	// 解析JavaScript代码
 	lexer := js.NewLexer(parse.NewInput(strings.NewReader(jsCode)))
@@ -73,7 +186,8 @@ func (c *Crawler) extractURLsFromJS(jsCode string) []string {
 
 		// 检查字符串中是否包含URL
 		if tt == js.StringToken || tt == js.TemplateToken {
-			str := string(text)
+			// 词法单元包含引号，需要先去掉
+			str := strings.Trim(string(text), "\"'`")
 			if strings.HasPrefix(str, "http") || strings.HasPrefix(str, "/") {
 				urls = append(urls, str)
 			}
@@ -83,148 +197,185 @@ func (c *Crawler) extractURLsFromJS(jsCode string) []string {
 	return urls
 }
 
-// normalizeURL 规范化URL
-func (c *Crawler) normalizeURL(rawURL, parentURL string) string {
-	// 处理相对路径
-	if strings.HasPrefix(rawURL, "/") {
-		parentURLObj, err := url.Parse(parentURL)
-		if err != nil {
-			return ""
-		}
-		rawURL = fmt.Sprintf("%s://%s%s", parentURLObj.Scheme, parentURLObj.Host, rawURL)
+// normalizeURL 规范化URL，以父URL为基准解析相对地址，并移除片段标识符
+func normalizeURL(rawURL, parentURL string) string {
+	parent, err := url.Parse(parentURL)
+	if err != nil {
+		return ""
 	}
-
-	// 解析URL
-	parsedURL, err := url.Parse(rawURL)
+	ref, err := url.Parse(strings.TrimSpace(rawURL))
 	if err != nil {
 		return ""
 	}
 
-	// 移除URL中的片段标识符
-	parsedURL.Fragment = ""
-
-	return parsedURL.String()
+	resolved := parent.ResolveReference(ref)
+	resolved.Fragment = ""
+	return resolved.String()
 }
 
-// crawlPage 爬取单个页面
-func (c *Crawler) crawlPage(pageURL string, depth int, resultsChan chan<- *CrawlResult) {
-	// 检查深度限制
-	if depth > c.Depth {
-		return
+// crawlPage 爬取单个页面，返回页面中发现的链接，链接深度为页面深度加一
+func (c *Crawler) crawlPage(t task) []*CrawlResult {
+	// 检查URL是否已访问
+	if _, visited := c.visited.LoadOrStore(t.URL, true); visited {
+		return nil
 	}
 
-	// 检查URL是否已访问
-	if _, visited := c.visited.LoadOrStore(pageURL, true); visited {
-		return
+	// 检查页面数量限制
+	if c.MaxPages > 0 && atomic.AddInt64(&c.pages, 1) > int64(c.MaxPages) {
+		return nil
 	}
 
-	// 创建新的页面
-	page := c.browser.MustPage(pageURL)
-	defer page.Close()
+	page, err := c.engine.Fetch(t.URL)
+	if err != nil {
+		c.Logger.Warnning(fmt.Sprintf("Failed to fetch %s: %v", t.URL, err))
+		return nil
+	}
 
-	// 设置超时
-	page.Timeout(c.Timeout)
+	// 记录表单与脚本中的对象键名
+	for _, form := range page.Forms {
+		c.endpoints.addForm(form, page.URL)
+	}
+	c.endpoints.addJSKeys(page.JSKeys, page.URL)
+	for _, request := range page.Requests {
+		c.endpoints.addRequest(request, page.URL)
+	}
 
-	// 等待页面加载完成
-	page.MustWaitLoad()
+	// 分析脚本的source map
+	if c.SourceMaps && isJavaScript(page.ContentType, page.URL) {
+		page.Links = append(page.Links, c.sourceMapLinks(page)...)
+	}
 
-	// 获取所有链接
-	elements := page.MustElements("a[href]")
-	for _, element := range elements {
-		href := element.MustAttribute("href")
-		if href == nil {
-			continue
+	// 检测HTML、内联脚本与脚本文件中的敏感信息
+	if c.Secrets && isText(page.ContentType) {
+		for _, secret := range c.secrets.scan(string(page.Body), page.URL) {
+			c.Logger.Warnning(fmt.Sprintf("Found %s [%s]: %s (%s:%d)", secret.Rule, secret.Severity, secret.Value, secret.URL, secret.Line))
 		}
+	}
 
-		normalizedURL := c.normalizeURL(*href, pageURL)
+	results := make([]*CrawlResult, 0, len(page.Links))
+	for _, link := range page.Links {
+		normalizedURL := normalizeURL(link.URL, page.URL)
 		if normalizedURL != "" {
-			resultsChan <- &CrawlResult{
+			results = append(results, &CrawlResult{
 				URL:       normalizedURL,
-				Depth:     depth,
-				ParentURL: pageURL,
-			}
+				Depth:     t.Depth + 1,
+				ParentURL: t.URL,
+				Kind:      link.Kind,
+			})
 		}
 	}
+	return results
+}
 
-	// 获取并分析页面上的JavaScript代码
-	scripts := page.MustElements("script")
-	for _, script := range scripts {
-		// 获取内联JavaScript
-		if jsCode, err := script.Text(); err == nil && jsCode != "" {
-			urls := c.extractURLsFromJS(jsCode)
-			for _, rawURL := range urls {
-				normalizedURL := c.normalizeURL(rawURL, pageURL)
-				if normalizedURL != "" {
-					resultsChan <- &CrawlResult{
-						URL:       normalizedURL,
-						Depth:     depth,
-						ParentURL: pageURL,
-					}
-				}
-			}
-		}
+// inScope 根据爬取范围规则判断结果是否需要请求，并记录原因
+func (c *Crawler) inScope(result *CrawlResult) bool {
+	u, err := url.Parse(result.URL)
+	if err != nil {
+		result.Reason = "invalid URL"
+		return false
+	}
+	if c.Scope == nil {
+		result.InScope = true
+		return true
+	}
+	result.InScope, result.Reason = c.Scope.Allowed(u)
+	return result.InScope
+}
 
-		// 获取外部JavaScript文件
-		if src := script.MustAttribute("src"); src != nil {
-			normalizedURL := c.normalizeURL(*src, pageURL)
-			if normalizedURL != "" {
-				resultsChan <- &CrawlResult{
-					URL:       normalizedURL,
-					Depth:     depth,
-					ParentURL: pageURL,
-				}
-			}
-		}
+// newPattern 判断URL的参数模式是否首次出现，参数值不同的URL只请求一次
+func (c *Crawler) newPattern(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
 	}
+	_, loaded := c.patterns.LoadOrStore(patternKey(u), true)
+	return !loaded
 }
 
 // Crawl 执行爬虫任务
+// 目标URL深度为0，深度小于Depth的页面会被请求，因此结果中链接的最大深度为Depth
 func (c *Crawler) Crawl() []*CrawlResult {
-	// 启动浏览器
-	url := launcher.New().
-		Headless(true).
-		Leakless(true).
-		MustLaunch()
-
-	c.browser = rod.New().ControlURL(url).MustConnect()
-	defer c.browser.MustClose()
+	// 创建爬取引擎
+	engine, err := NewEngine(c.Engine, c)
+	if err != nil {
+		c.Logger.LoggerError(err.Error())
+		return nil
+	}
+	c.engine = engine
+	c.endpoints = newEndpointSet()
+	c.secrets = newSecretScanner(c.SecretRules, c.SecretAllowlist)
+	c.client = &http.Client{Timeout: c.Timeout}
+	c.findings = make([]Finding, 0)
+	defer c.engine.Close()
+
+	concurrent := c.Concurrent
+	if concurrent <= 0 {
+		concurrent = 1
+	}
 
 	results := make([]*CrawlResult, 0)
-	resultsChan := make(chan *CrawlResult, 1000)
-	wg := sync.WaitGroup{}
+	seen := make(map[string]bool)
+	mu := sync.Mutex{}
+	queue := newFrontier()
+
+	// 记录结果，并将范围内、深度未达到限制且URL模式未请求过的页面加入队列
+	collect := func(found []*CrawlResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, result := range found {
+			if seen[result.URL] {
+				continue
+			}
+			seen[result.URL] = true
 
-	// 创建工作池
-	jobs := make(chan string, c.Concurrent)
-	for i := 0; i < c.Concurrent; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for url := range jobs {
-				c.crawlPage(url, 1, resultsChan)
+			// 范围外的URL只记录不请求
+			if !c.inScope(result) {
+				c.Logger.Info(fmt.Sprintf("Out of scope URL: %s (%s)", result.URL, result.Reason))
+				results = append(results, result)
+				continue
 			}
-		}()
+			c.Logger.Success(fmt.Sprintf("Found URL: %s (Depth: %d, Parent: %s)", result.URL, result.Depth, result.ParentURL))
+			results = append(results, result)
+			if result.Kind != LinkXHR {
+				c.endpoints.addQuery(result.URL, result.ParentURL)
+			}
+
+			// API请求与source map的响应不是页面，只记录不请求
+			if result.Kind != LinkXHR && result.Kind != LinkSourceMap && result.Depth < c.Depth && c.newPattern(result.URL) {
+				queue.push(task{URL: result.URL, Depth: result.Depth})
+			}
+		}
 	}
 
 	// 发送初始URL
-	jobs <- c.Target
+	seen[c.Target] = true
+	c.newPattern(c.Target)
+	queue.push(task{URL: c.Target, Depth: 0})
 
-	// 处理结果
-	go func() {
-		for result := range resultsChan {
-			c.Logger.Success(fmt.Sprintf("Found URL: %s (Depth: %d, Parent: %s)", result.URL, result.Depth, result.ParentURL))
-			results = append(results, result)
+	// 从robots.txt、sitemap与 .well-known 文件收集初始链接
+	if c.Seed {
+		collect(c.seed())
+	}
 
-			// 如果深度未达到限制，将新URL加入任务队列
-			if result.Depth < c.Depth {
-				jobs <- result.URL
+	// 创建工作池，新发现的页面在标记完成之前加入队列
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, ok := queue.pop()
+				if !ok {
+					return
+				}
+				collect(c.crawlPage(t))
+				queue.done()
 			}
-		}
-	}()
+		}()
+	}
 
 	// 等待所有任务完成
 	wg.Wait()
-	close(jobs)
-	close(resultsChan)
 
 	return results
-}
\ No newline at end of file
+}
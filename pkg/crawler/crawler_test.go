@@ -0,0 +1,224 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seaung/nox/pkg/utils"
+)
+
+// crawlDeadline 单次爬取允许的最长时间，超过即认为爬取无法结束
+const crawlDeadline = 10 * time.Second
+
+// newTestCrawler 创建使用HTTP引擎、不收集种子文件且不输出日志的爬虫
+func newTestCrawler(t *testing.T, target string) *Crawler {
+	t.Helper()
+	c := NewCrawler(target)
+	if err := c.SetEngine(EngineHTTP); err != nil {
+		t.Fatalf("SetEngine: %v", err)
+	}
+	c.SetSeed(false)
+	c.SetSecrets(false)
+	c.Logger = utils.NewWithWriter(io.Discard)
+	return c
+}
+
+// findResult 返回指定地址的爬取结果
+func findResult(results []*CrawlResult, rawURL string) *CrawlResult {
+	for _, result := range results {
+		if result.URL == rawURL {
+			return result
+		}
+	}
+	return nil
+}
+
+// hitCounter 记录每个路径被请求的次数
+type hitCounter struct {
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func (h *hitCounter) add(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hits[path]++
+}
+
+func (h *hitCounter) get(path string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hits[path]
+}
+
+func (h *hitCounter) total() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	total := 0
+	for _, count := range h.hits {
+		total += count
+	}
+	return total
+}
+
+// newLinkServer 创建测试站点，links返回每个路径页面中的链接
+func newLinkServer(links func(path string) []string) (*httptest.Server, *hitCounter) {
+	counter := &hitCounter{hits: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.add(r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+		for _, link := range links(r.URL.Path) {
+			fmt.Fprintf(w, `<a href="%s">%s</a>`, link, link)
+		}
+	}))
+	return server, counter
+}
+
+// crawlWithDeadline 执行爬取，超过crawlDeadline仍未返回时测试失败
+func crawlWithDeadline(t *testing.T, c *Crawler) []*CrawlResult {
+	t.Helper()
+	done := make(chan []*CrawlResult, 1)
+	go func() {
+		done <- c.Crawl()
+	}()
+	select {
+	case results := <-done:
+		return results
+	case <-time.After(crawlDeadline):
+		t.Fatalf("Crawl() did not return within %s", crawlDeadline)
+		return nil
+	}
+}
+
+// TestCrawlDepth 深度限制：结果深度不超过Depth，深度为Depth的页面只记录不请求，环路中的页面只请求一次
+func TestCrawlDepth(t *testing.T) {
+	// /chain/N 链接到 /chain/N+1，同时链接回首页与自身形成环路
+	server, counter := newLinkServer(func(path string) []string {
+		if path == "/" {
+			return []string{"/chain/1"}
+		}
+		var n int
+		fmt.Sscanf(path, "/chain/%d", &n)
+		return []string{"/", path, fmt.Sprintf("/chain/%d", n+1)}
+	})
+	defer server.Close()
+
+	for _, concurrent := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrent=%d", concurrent), func(t *testing.T) {
+			counter.mu.Lock()
+			counter.hits = make(map[string]int)
+			counter.mu.Unlock()
+
+			c := newTestCrawler(t, server.URL+"/")
+			c.SetDepth(3)
+			c.SetConcurrent(concurrent)
+			results := crawlWithDeadline(t, c)
+
+			for _, result := range results {
+				if result.Depth > c.Depth {
+					t.Errorf("result %s has depth %d > %d", result.URL, result.Depth, c.Depth)
+				}
+			}
+			if last := findResult(results, server.URL+"/chain/3"); last == nil || last.Depth != 3 {
+				t.Errorf("result at max depth = %+v, want /chain/3 at depth 3", last)
+			}
+			if findResult(results, server.URL+"/chain/4") != nil {
+				t.Error("found /chain/4 beyond max depth")
+			}
+
+			for _, path := range []string{"/", "/chain/1", "/chain/2"} {
+				if hits := counter.get(path); hits != 1 {
+					t.Errorf("%s fetched %d times, want 1", path, hits)
+				}
+			}
+			if hits := counter.get("/chain/3"); hits != 0 {
+				t.Errorf("page at max depth fetched %d times, want 0", hits)
+			}
+		})
+	}
+}
+
+// TestCrawlTerminates 并发、页面数量限制与全部请求失败时爬取都能结束
+func TestCrawlTerminates(t *testing.T) {
+	// 每个页面链接到5个子页面，并链接回首页
+	wide, wideCounter := newLinkServer(func(path string) []string {
+		links := []string{"/"}
+		for i := 0; i < 5; i++ {
+			links = append(links, strings.TrimSuffix(path, "/")+fmt.Sprintf("/%d", i))
+		}
+		return links
+	})
+	defer wide.Close()
+
+	t.Run("concurrent", func(t *testing.T) {
+		c := newTestCrawler(t, wide.URL+"/")
+		c.SetDepth(3)
+		c.SetConcurrent(8)
+		results := crawlWithDeadline(t, c)
+
+		// 深度1与2各5与25个页面被请求，深度3的125个页面只记录
+		if got, want := len(results), 5+25+125; got != want {
+			t.Errorf("got %d results, want %d", got, want)
+		}
+		if got, want := wideCounter.total(), 1+5+25; got != want {
+			t.Errorf("fetched %d pages, want %d", got, want)
+		}
+	})
+
+	t.Run("max pages", func(t *testing.T) {
+		wideCounter.mu.Lock()
+		wideCounter.hits = make(map[string]int)
+		wideCounter.mu.Unlock()
+
+		c := newTestCrawler(t, wide.URL+"/")
+		c.SetDepth(5)
+		c.SetConcurrent(4)
+		c.SetMaxPages(7)
+		crawlWithDeadline(t, c)
+		if got := wideCounter.total(); got > 7 {
+			t.Errorf("fetched %d pages, want at most 7", got)
+		}
+	})
+
+	t.Run("all fetches fail", func(t *testing.T) {
+		// 首页之外的页面都在返回响应前断开连接
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			for i := 0; i < 10; i++ {
+				fmt.Fprintf(w, `<a href="/broken/%d">x</a>`, i)
+			}
+		}))
+		defer failing.Close()
+
+		c := newTestCrawler(t, failing.URL+"/")
+		c.SetConcurrent(4)
+		if results := crawlWithDeadline(t, c); len(results) != 10 {
+			t.Errorf("got %d results, want 10", len(results))
+		}
+	})
+
+	t.Run("target unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		c := newTestCrawler(t, closed.URL+"/")
+		c.SetSeed(true)
+		c.SetConcurrent(4)
+		if results := crawlWithDeadline(t, c); len(results) != 0 {
+			t.Errorf("got %d results, want 0", len(results))
+		}
+	})
+}
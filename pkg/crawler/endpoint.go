@@ -0,0 +1,311 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// 接口来源
+const (
+	SourceForm  = "form"  // HTML表单
+	SourceQuery = "query" // URL中的查询参数
+	SourceJS    = "js"    // JavaScript中的对象键名
+	SourceXHR   = "xhr"   // 浏览器中捕获的XHR/fetch请求
+)
+
+// 参数位置
+const (
+	LocationQuery = "query" // URL查询参数
+	LocationBody  = "body"  // 表单请求体
+	LocationJSON  = "json"  // JSON对象键名
+)
+
+// defaultEnctype 表单默认的编码类型
+const defaultEnctype = "application/x-www-form-urlencoded"
+
+// Form 页面中的HTML表单
+type Form struct {
+	Action  string  // 提交地址 (绝对地址)
+	Method  string  // 请求方法，GET或POST
+	Enctype string  // 编码类型
+	Inputs  []Input // 表单字段
+}
+
+// Input 表单字段
+type Input struct {
+	Name    string // 字段名
+	Type    string // 字段类型，例如text、hidden、password、textarea、select
+	Default string // 默认值
+}
+
+// Param 接口参数
+type Param struct {
+	Name     string // 参数名
+	Type     string // 参数类型，表单字段类型，其他来源为空
+	Default  string // 默认值
+	Location string // 参数位置 query/body/json
+}
+
+// Endpoint 爬取过程中发现的接口，用于后续的模糊测试
+type Endpoint struct {
+	Method  string            // 请求方法，JavaScript来源为空
+	URL     string            // 接口地址，不含查询参数
+	Enctype string            // 请求体编码类型，表单与XHR请求
+	Source  string            // 来源 form/query/js/xhr
+	PageURL string            // 首次发现该接口的页面
+	Params  []Param           // 参数列表
+	Headers map[string]string // 首次捕获的请求头，仅XHR请求
+	Body    string            // 首次捕获的请求体，仅XHR请求
+}
+
+// endpointSet 按来源、方法与地址合并的接口集合
+type endpointSet struct {
+	mu        sync.Mutex
+	endpoints map[string]*Endpoint
+}
+
+// newEndpointSet 创建接口集合
+func newEndpointSet() *endpointSet {
+	return &endpointSet{endpoints: make(map[string]*Endpoint)}
+}
+
+// add 添加接口，相同来源、方法与地址的接口合并参数
+func (s *endpointSet) add(endpoint *Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := endpoint.Source + " " + endpoint.Method + " " + endpoint.URL
+	existing, ok := s.endpoints[key]
+	if !ok {
+		s.endpoints[key] = endpoint
+		return
+	}
+	for _, param := range endpoint.Params {
+		if !hasParam(existing.Params, param) {
+			existing.Params = append(existing.Params, param)
+		}
+	}
+}
+
+// addForm 将表单记录为接口，action中的查询参数同样作为参数
+func (s *endpointSet) addForm(form Form, pageURL string) {
+	endpoint, query := splitEndpointURL(form.Action)
+	if endpoint == "" {
+		return
+	}
+
+	location := LocationBody
+	if form.Method == "GET" {
+		location = LocationQuery
+	}
+	params := queryParams(query)
+	for _, input := range form.Inputs {
+		params = append(params, Param{Name: input.Name, Type: input.Type, Default: input.Default, Location: location})
+	}
+
+	s.add(&Endpoint{
+		Method:  form.Method,
+		URL:     endpoint,
+		Enctype: form.Enctype,
+		Source:  SourceForm,
+		PageURL: pageURL,
+		Params:  params,
+	})
+}
+
+// addQuery 将带查询参数的URL记录为接口
+func (s *endpointSet) addQuery(rawURL, pageURL string) {
+	endpoint, query := splitEndpointURL(rawURL)
+	if endpoint == "" || len(query) == 0 {
+		return
+	}
+	s.add(&Endpoint{
+		Method:  "GET",
+		URL:     endpoint,
+		Source:  SourceQuery,
+		PageURL: pageURL,
+		Params:  queryParams(query),
+	})
+}
+
+// addJSKeys 将JavaScript中出现的对象键名记录为所在页面的接口参数
+func (s *endpointSet) addJSKeys(keys []string, pageURL string) {
+	endpoint, _ := splitEndpointURL(pageURL)
+	if endpoint == "" || len(keys) == 0 {
+		return
+	}
+	params := make([]Param, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, Param{Name: key, Location: LocationJSON})
+	}
+	s.add(&Endpoint{
+		URL:     endpoint,
+		Source:  SourceJS,
+		PageURL: pageURL,
+		Params:  params,
+	})
+}
+
+// addRequest 将浏览器中捕获的XHR/fetch请求记录为API接口
+// 请求体为JSON对象时记录其键名，为表单编码时记录其字段名
+func (s *endpointSet) addRequest(request APIRequest, pageURL string) {
+	endpoint, query := splitEndpointURL(request.URL)
+	if endpoint == "" {
+		return
+	}
+
+	enctype := ""
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			enctype = value
+		}
+	}
+
+	params := queryParams(query)
+	body := strings.TrimSpace(request.Body)
+	var object map[string]interface{}
+	switch {
+	case body == "":
+	case json.Unmarshal([]byte(body), &object) == nil:
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			params = append(params, Param{Name: name, Default: jsonDefault(object[name]), Location: LocationJSON})
+		}
+	default:
+		if form, err := url.ParseQuery(body); err == nil && strings.Contains(body, "=") {
+			for _, param := range queryParams(form) {
+				param.Location = LocationBody
+				params = append(params, param)
+			}
+		}
+	}
+
+	s.add(&Endpoint{
+		Method:  request.Method,
+		URL:     endpoint,
+		Enctype: enctype,
+		Source:  SourceXHR,
+		PageURL: pageURL,
+		Params:  params,
+		Headers: request.Headers,
+		Body:    request.Body,
+	})
+}
+
+// list 返回按地址、来源与方法排序的接口列表
+func (s *endpointSet) list() []*Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoints := make([]*Endpoint, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].URL != endpoints[j].URL {
+			return endpoints[i].URL < endpoints[j].URL
+		}
+		if endpoints[i].Source != endpoints[j].Source {
+			return endpoints[i].Source < endpoints[j].Source
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return endpoints
+}
+
+// extractJSONKeysFromJS 从JavaScript代码中提取对象字面量的键名，例如 {user: 1, "pass": 2}
+func extractJSONKeysFromJS(jsCode string) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	lexer := js.NewLexer(parse.NewInput(strings.NewReader(jsCode)))
+
+	// 只有紧跟在 { 或 , 之后且后面是 : 的标识符或字符串才是键名
+	var prev, candidate string
+	for {
+		tt, text := lexer.Next()
+		if tt == js.ErrorToken {
+			break
+		}
+		if tt == js.WhitespaceToken || tt == js.LineTerminatorToken || tt == js.CommentToken || tt == js.CommentLineTerminatorToken {
+			continue
+		}
+
+		token := string(text)
+		if token == ":" && candidate != "" && !seen[candidate] {
+			seen[candidate] = true
+			keys = append(keys, candidate)
+		}
+
+		candidate = ""
+		if prev == "{" || prev == "," {
+			switch {
+			case tt == js.StringToken:
+				candidate = strings.Trim(token, "\"'")
+			case js.IsIdentifierName(tt):
+				candidate = token
+			}
+		}
+		prev = token
+	}
+
+	return keys
+}
+
+// splitEndpointURL 返回去掉查询参数与片段的地址以及查询参数，只接受http(s)地址
+func splitEndpointURL(rawURL string) (string, url.Values) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", nil
+	}
+	query := u.Query()
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), query
+}
+
+// queryParams 将查询参数转换为接口参数，按参数名排序
+func queryParams(query url.Values) []Param {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]Param, 0, len(names))
+	for _, name := range names {
+		params = append(params, Param{Name: name, Default: query.Get(name), Location: LocationQuery})
+	}
+	return params
+}
+
+// jsonDefault 返回JSON值的字符串形式，对象与数组返回空
+func jsonDefault(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// hasParam 判断参数列表中是否已有相同位置的同名参数
+func hasParam(params []Param, param Param) bool {
+	for _, p := range params {
+		if p.Name == param.Name && p.Location == param.Location {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// paramList 将参数格式化为 名称@位置=默认值 的列表
+func paramList(params []Param) string {
+	list := make([]string, 0, len(params))
+	for _, param := range params {
+		list = append(list, param.Name+"@"+param.Location+"="+param.Default)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestEndpointSetAddForm(t *testing.T) {
+	tests := []struct {
+		name   string
+		forms  []Form
+		want   int    // 接口数量
+		params string // 第一个接口的参数
+	}{
+		{
+			name: "post form with action query",
+			forms: []Form{{Action: "http://127.0.0.1/login?next=%2Fhome#top", Method: "POST", Enctype: defaultEnctype, Inputs: []Input{
+				{Name: "user", Type: "text"}, {Name: "csrf", Type: "hidden", Default: "abc"},
+			}}},
+			want:   1,
+			params: "next@query=/home,user@body=,csrf@body=abc",
+		},
+		{
+			name:   "get form",
+			forms:  []Form{{Action: "http://127.0.0.1/search", Method: "GET", Inputs: []Input{{Name: "q", Type: "text"}}}},
+			want:   1,
+			params: "q@query=",
+		},
+		{
+			name: "merge forms with the same action",
+			forms: []Form{
+				{Action: "http://127.0.0.1/login", Method: "POST", Inputs: []Input{{Name: "user"}, {Name: "pass"}}},
+				{Action: "http://127.0.0.1/login?x=1", Method: "POST", Inputs: []Input{{Name: "pass"}, {Name: "otp"}}},
+			},
+			want:   1,
+			params: "user@body=,pass@body=,x@query=1,otp@body=",
+		},
+		{
+			name: "different methods are separate endpoints",
+			forms: []Form{
+				{Action: "http://127.0.0.1/a", Method: "GET", Inputs: []Input{{Name: "id"}}},
+				{Action: "http://127.0.0.1/a", Method: "POST", Inputs: []Input{{Name: "id"}}},
+			},
+			want:   2,
+			params: "id@query=",
+		},
+		{
+			name:  "non-http action",
+			forms: []Form{{Action: "javascript:void(0)", Method: "POST", Inputs: []Input{{Name: "id"}}}},
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newEndpointSet()
+			for _, form := range tt.forms {
+				set.addForm(form, "http://127.0.0.1/")
+			}
+			endpoints := set.list()
+			if len(endpoints) != tt.want {
+				t.Fatalf("list() = %+v, want %d endpoints", endpoints, tt.want)
+			}
+			if tt.want == 0 {
+				return
+			}
+			if got := paramList(endpoints[0].Params); got != tt.params {
+				t.Errorf("params = %s, want %s", got, tt.params)
+			}
+			if endpoints[0].Source != SourceForm || strings.ContainsAny(endpoints[0].URL, "?#") || endpoints[0].PageURL != "http://127.0.0.1/" {
+				t.Errorf("endpoint = %+v", endpoints[0])
+			}
+		})
+	}
+}
+
+func TestEndpointSetAddQuery(t *testing.T) {
+	set := newEndpointSet()
+	set.addQuery("http://127.0.0.1/item?id=1&sort=asc", "http://127.0.0.1/")
+	set.addQuery("http://127.0.0.1/item?id=2&page=3", "http://127.0.0.1/list")
+	set.addQuery("http://127.0.0.1/about", "http://127.0.0.1/")
+	set.addQuery("mailto:a@b.c?subject=x", "http://127.0.0.1/")
+
+	endpoints := set.list()
+	if len(endpoints) != 1 {
+		t.Fatalf("list() = %+v, want 1 endpoint", endpoints)
+	}
+	endpoint := endpoints[0]
+	// 合并时保留首次发现的页面与默认值
+	want := &Endpoint{
+		Method:  "GET",
+		URL:     "http://127.0.0.1/item",
+		Source:  SourceQuery,
+		PageURL: "http://127.0.0.1/",
+		Params: []Param{
+			{Name: "id", Default: "1", Location: LocationQuery},
+			{Name: "sort", Default: "asc", Location: LocationQuery},
+			{Name: "page", Default: "3", Location: LocationQuery},
+		},
+	}
+	if !reflect.DeepEqual(endpoint, want) {
+		t.Errorf("endpoint = %+v, want %+v", endpoint, want)
+	}
+}
+
+func TestEndpointSetAddRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []APIRequest
+		enctype  string
+		params   string
+	}{
+		{
+			name: "json body",
+			requests: []APIRequest{{Method: "POST", URL: "http://127.0.0.1/api/login?v=2", Headers: map[string]string{"content-type": "application/json"},
+				Body: `{"user":"admin","remember":true,"age":3,"meta":{"a":1}}`}},
+			enctype: "application/json",
+			params:  "v@query=2,age@json=3,meta@json=,remember@json=true,user@json=admin",
+		},
+		{
+			name: "form body",
+			requests: []APIRequest{{Method: "POST", URL: "http://127.0.0.1/api/login", Headers: map[string]string{"Content-Type": defaultEnctype},
+				Body: "user=admin&pass=x"}},
+			enctype: defaultEnctype,
+			params:  "pass@body=x,user@body=admin",
+		},
+		{
+			name:     "plain text body",
+			requests: []APIRequest{{Method: "POST", URL: "http://127.0.0.1/api/log", Body: "hello world"}},
+			params:   "",
+		},
+		{
+			name:     "json array body",
+			requests: []APIRequest{{Method: "PUT", URL: "http://127.0.0.1/api/items", Body: `[{"id":1}]`}},
+			params:   "",
+		},
+		{
+			name: "merge json and form parameters",
+			requests: []APIRequest{
+				{Method: "POST", URL: "http://127.0.0.1/api/user", Body: `{"name":"a","id":1}`},
+				{Method: "POST", URL: "http://127.0.0.1/api/user", Body: "name=b&role=admin"},
+				{Method: "POST", URL: "http://127.0.0.1/api/user", Body: `{"id":2,"email":"x"}`},
+			},
+			params: "id@json=1,name@json=a,name@body=b,role@body=admin,email@json=x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newEndpointSet()
+			for _, request := range tt.requests {
+				set.addRequest(request, "http://127.0.0.1/")
+			}
+			endpoints := set.list()
+			if len(endpoints) != 1 {
+				t.Fatalf("list() = %+v, want 1 endpoint", endpoints)
+			}
+			endpoint := endpoints[0]
+			if got := paramList(endpoint.Params); got != tt.params {
+				t.Errorf("params = %s, want %s", got, tt.params)
+			}
+			if endpoint.Enctype != tt.enctype || endpoint.Source != SourceXHR || endpoint.Method != tt.requests[0].Method {
+				t.Errorf("endpoint = %+v, want enctype %q from %s", endpoint, tt.enctype, SourceXHR)
+			}
+			// 合并时保留首次捕获的请求体
+			if endpoint.Body != tt.requests[0].Body {
+				t.Errorf("Body = %q, want %q", endpoint.Body, tt.requests[0].Body)
+			}
+		})
+	}
+}
+
+func TestExtractJSONKeysFromJS(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{`fetch("/api", {body: JSON.stringify({user: u, "pass": p, 'otp': 1})})`, []string{"body", "user", "pass", "otp"}},
+		{`var a = {id: 1, id: 2}; var b = {id: 3, name: "x"}`, []string{"id", "name"}},
+		{"{\n  // comment\n  token /* c */ : t,\n  nested: {deep: 1}\n}", []string{"token", "nested", "deep"}},
+		{`x ? a : b; label: for (;;) {}; switch (v) { case 1: break }`, []string{}},
+		{`{default: 1, class: 2}`, []string{"default", "class"}},
+		{`f(a, b); var s = "{key: 1}"`, []string{}},
+		{``, []string{}},
+	}
+	for _, tt := range tests {
+		if got := extractJSONKeysFromJS(tt.code); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractJSONKeysFromJS(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
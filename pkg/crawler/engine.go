@@ -0,0 +1,293 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 爬取引擎
+const (
+	EngineHTTP    = "http"    // 使用标准HTTP客户端请求并解析HTML
+	EngineBrowser = "browser" // 使用无头浏览器渲染页面
+)
+
+// 链接来源
+const (
+	LinkAnchor    = "a"         // a/area标签
+	LinkLink      = "link"      // link标签
+	LinkForm      = "form"      // 表单action
+	LinkScript    = "script"    // 外部脚本
+	LinkFrame     = "iframe"    // iframe/frame
+	LinkMedia     = "media"     // img/source/video等资源，包括srcset
+	LinkRefresh   = "refresh"   // meta refresh跳转
+	LinkRedirect  = "redirect"  // HTTP跳转
+	LinkJS        = "js"        // JavaScript代码中的字符串
+	LinkXHR       = "xhr"       // 浏览器中捕获的XHR/fetch请求
+	LinkClick     = "click"     // 点击元素后跳转的页面
+	LinkRoute     = "route"     // 前端路由定义中的路径
+	LinkChunk     = "chunk"     // webpack按需加载的分块文件
+	LinkSource    = "source"    // source map还原的源代码中的字符串
+	LinkSourceMap = "sourcemap" // 脚本的source map文件
+)
+
+// Engine 爬取引擎，负责获取页面并提取其中的链接
+type Engine interface {
+	Fetch(pageURL string) (*Page, error) // 获取页面
+	Close() error                        // 释放引擎占用的资源
+}
+
+// Page 引擎获取到的页面
+type Page struct {
+	URL         string       // 页面地址 (跟随跳转后)
+	StatusCode  int          // 状态码，浏览器引擎无法获取时为0
+	ContentType string       // 内容类型
+	Body        []byte       // 页面内容，浏览器引擎为渲染后的HTML
+	Links       []Link       // 页面中发现的链接
+	Forms       []Form       // 页面中的表单
+	JSKeys      []string     // 页面脚本中出现的对象键名
+	Requests    []APIRequest // 页面加载期间发出的XHR/fetch请求，仅浏览器引擎
+}
+
+// APIRequest 浏览器中捕获的XHR/fetch请求
+type APIRequest struct {
+	Method  string            // 请求方法
+	URL     string            // 请求地址
+	Headers map[string]string // 请求头
+	Body    string            // 请求体
+	Type    string            // 请求类型 xhr/fetch
+}
+
+// Link 页面中发现的链接
+type Link struct {
+	URL  string // 绝对地址
+	Kind string // 链接来源
+}
+
+// NewEngine 根据名称创建爬取引擎
+func NewEngine(name string, c *Crawler) (Engine, error) {
+	switch name {
+	case EngineHTTP:
+		engine := NewHTTPEngine(c.Timeout)
+		engine.Scope = c.Scope
+		return engine, nil
+	case EngineBrowser:
+		engine := NewBrowserEngine(c.Timeout)
+		engine.Click = c.Click
+		engine.http.Scope = c.Scope
+		return engine, nil
+	}
+	return nil, fmt.Errorf("unknown crawler engine %q", name)
+}
+
+// parseHTML 解析HTML页面，提取链接、表单、脚本、框架、srcset与meta refresh，并分析内联脚本
+// 结果追加到page的Links、Forms与JSKeys中，相对地址以page.URL为基准解析
+func parseHTML(page *Page, body io.Reader) {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return
+	}
+
+	resolve := func(raw string) string {
+		ref, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return ""
+		}
+		return base.ResolveReference(ref).String()
+	}
+	add := func(raw, kind string) {
+		if strings.TrimSpace(raw) == "" {
+			return
+		}
+		if resolved := resolve(raw); resolved != "" {
+			page.Links = append(page.Links, Link{URL: resolved, Kind: kind})
+		}
+	}
+
+	seenKeys := make(map[string]bool, len(page.JSKeys))
+	for _, key := range page.JSKeys {
+		seenKeys[key] = true
+	}
+	addScript := func(code string) {
+		for _, raw := range extractURLsFromJS(code) {
+			add(raw, LinkJS)
+		}
+		for _, key := range extractJSONKeysFromJS(code) {
+			if !seenKeys[key] {
+				seenKeys[key] = true
+				page.JSKeys = append(page.JSKeys, key)
+			}
+		}
+	}
+
+	// 当前表单及正在解析的textarea、select字段
+	var form *Form
+	textarea, selectField := -1, -1
+	optionSeen, selected, optionText := false, false, false
+
+	tokenizer := html.NewTokenizer(body)
+	inScript := false
+	for {
+		tt := tokenizer.Next()
+		switch tt {
+		case html.ErrorToken:
+			if form != nil {
+				page.Forms = append(page.Forms, *form)
+			}
+			return
+		case html.TextToken:
+			text := string(tokenizer.Text())
+			switch {
+			case inScript:
+				addScript(text)
+			case form != nil && textarea >= 0:
+				form.Inputs[textarea].Default += text
+			case form != nil && selectField >= 0 && optionText:
+				form.Inputs[selectField].Default = strings.TrimSpace(text)
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "script":
+				inScript = false
+			case "form":
+				if form != nil {
+					page.Forms = append(page.Forms, *form)
+					form = nil
+				}
+			case "textarea":
+				textarea = -1
+			case "select":
+				selectField = -1
+			case "option":
+				optionText = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			attrs := make(map[string]string, len(token.Attr))
+			for _, attr := range token.Attr {
+				attrs[attr.Key] = attr.Val
+			}
+
+			switch token.Data {
+			case "base":
+				// base标签改变后续相对地址的解析基准
+				if ref, err := url.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+					base = base.ResolveReference(ref)
+				}
+			case "a", "area":
+				add(attrs["href"], LinkAnchor)
+			case "link":
+				add(attrs["href"], LinkLink)
+			case "form":
+				if form != nil {
+					page.Forms = append(page.Forms, *form)
+				}
+				form = newForm(attrs, resolve)
+				add(form.Action, LinkForm)
+			case "input", "button":
+				if form != nil && attrs["name"] != "" {
+					form.Inputs = append(form.Inputs, newInput(token.Data, attrs))
+				}
+			case "textarea":
+				if form != nil && attrs["name"] != "" {
+					form.Inputs = append(form.Inputs, Input{Name: attrs["name"], Type: "textarea"})
+					textarea = len(form.Inputs) - 1
+				}
+			case "select":
+				if form != nil && attrs["name"] != "" {
+					form.Inputs = append(form.Inputs, Input{Name: attrs["name"], Type: "select"})
+					selectField = len(form.Inputs) - 1
+					optionSeen, selected = false, false
+				}
+			case "option":
+				// select的默认值为带selected属性的选项，没有时为第一个选项，选项没有value时取其文本
+				optionText = false
+				if form == nil || selectField < 0 {
+					break
+				}
+				value, hasValue := attrs["value"]
+				_, isSelected := attrs["selected"]
+				if isSelected || (!selected && !optionSeen) {
+					form.Inputs[selectField].Default = value
+					optionText = !hasValue
+					selected = selected || isSelected
+				}
+				optionSeen = true
+			case "script":
+				add(attrs["src"], LinkScript)
+				inScript = attrs["src"] == "" && tt == html.StartTagToken
+			case "iframe", "frame":
+				add(attrs["src"], LinkFrame)
+			case "img", "source", "video", "audio", "embed", "track":
+				add(attrs["src"], LinkMedia)
+				for _, candidate := range parseSrcset(attrs["srcset"]) {
+					add(candidate, LinkMedia)
+				}
+			case "object":
+				add(attrs["data"], LinkMedia)
+			case "meta":
+				if strings.EqualFold(attrs["http-equiv"], "refresh") {
+					add(parseRefresh(attrs["content"]), LinkRefresh)
+				}
+			}
+		}
+	}
+}
+
+// newForm 根据form标签的属性创建表单，没有action的表单提交到当前页面
+func newForm(attrs map[string]string, resolve func(string) string) *Form {
+	form := &Form{
+		Action:  resolve(attrs["action"]),
+		Method:  strings.ToUpper(strings.TrimSpace(attrs["method"])),
+		Enctype: strings.ToLower(strings.TrimSpace(attrs["enctype"])),
+		Inputs:  make([]Input, 0),
+	}
+	if form.Method != "POST" {
+		form.Method = "GET"
+	}
+	if form.Enctype == "" {
+		form.Enctype = defaultEnctype
+	}
+	return form
+}
+
+// newInput 根据input或button标签的属性创建表单字段
+func newInput(tag string, attrs map[string]string) Input {
+	inputType := strings.ToLower(attrs["type"])
+	if inputType == "" {
+		inputType = "text"
+		if tag == "button" {
+			inputType = "submit"
+		}
+	}
+	return Input{Name: attrs["name"], Type: inputType, Default: attrs["value"]}
+}
+
+// parseSrcset 解析srcset属性中的候选地址
+func parseSrcset(srcset string) []string {
+	candidates := make([]string, 0)
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 {
+			candidates = append(candidates, fields[0])
+		}
+	}
+	return candidates
+}
+
+// parseRefresh 解析meta refresh的content属性，例如 "0; url=/home"
+func parseRefresh(content string) string {
+	_, target, ok := strings.Cut(content, ";")
+	if !ok {
+		return ""
+	}
+	target = strings.TrimSpace(target)
+	if len(target) >= 4 && strings.EqualFold(target[:4], "url=") {
+		target = target[4:]
+	}
+	return strings.Trim(strings.TrimSpace(target), `'"`)
+}
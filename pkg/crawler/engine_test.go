@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hasLink 判断链接列表中是否包含指定链接
+func hasLink(links []Link, want Link) bool {
+	for _, link := range links {
+		if link == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseHTMLLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		links []Link
+		keys  []string
+	}{
+		{
+			name: "anchors",
+			html: `<a href="/about">About</a><a href="news?id=1">News</a><area href="https://other.test/map"><a href="">empty</a>`,
+			links: []Link{
+				{URL: "http://site.test/about", Kind: LinkAnchor},
+				{URL: "http://site.test/dir/news?id=1", Kind: LinkAnchor},
+				{URL: "https://other.test/map", Kind: LinkAnchor},
+			},
+		},
+		{
+			name: "base",
+			html: `<a href="before">x</a><base href="/static/"><a href="after">y</a><link rel="stylesheet" href="app.css">`,
+			links: []Link{
+				{URL: "http://site.test/dir/before", Kind: LinkAnchor},
+				{URL: "http://site.test/static/after", Kind: LinkAnchor},
+				{URL: "http://site.test/static/app.css", Kind: LinkLink},
+			},
+		},
+		{
+			name: "form action",
+			html: `<form action="/login" method="post"></form><form></form>`,
+			links: []Link{
+				{URL: "http://site.test/login", Kind: LinkForm},
+				{URL: "http://site.test/dir/page.html", Kind: LinkForm},
+			},
+		},
+		{
+			name: "iframes and scripts",
+			html: `<iframe src="/embed"></iframe><frame src="frame.html"><script src="/app.js"></script>`,
+			links: []Link{
+				{URL: "http://site.test/embed", Kind: LinkFrame},
+				{URL: "http://site.test/dir/frame.html", Kind: LinkFrame},
+				{URL: "http://site.test/app.js", Kind: LinkScript},
+			},
+		},
+		{
+			name: "srcset",
+			html: `<img src="/a.png" srcset="/a-1x.png 1x, /a-2x.png 2x"><picture><source srcset="/b.webp 480w,/c.webp 800w"></picture><object data="/doc.pdf"></object>`,
+			links: []Link{
+				{URL: "http://site.test/a.png", Kind: LinkMedia},
+				{URL: "http://site.test/a-1x.png", Kind: LinkMedia},
+				{URL: "http://site.test/a-2x.png", Kind: LinkMedia},
+				{URL: "http://site.test/b.webp", Kind: LinkMedia},
+				{URL: "http://site.test/c.webp", Kind: LinkMedia},
+				{URL: "http://site.test/doc.pdf", Kind: LinkMedia},
+			},
+		},
+		{
+			name: "meta refresh",
+			html: `<meta http-equiv="Refresh" content="0; URL='/home'">`,
+			links: []Link{
+				{URL: "http://site.test/home", Kind: LinkRefresh},
+			},
+		},
+		{
+			name: "inline script",
+			html: `<script>fetch("/api/users", {method: "POST", body: JSON.stringify({username: u, "token": t})})</script><script src="/x.js">var ignored = "/not-parsed"</script>`,
+			links: []Link{
+				{URL: "http://site.test/api/users", Kind: LinkJS},
+				{URL: "http://site.test/x.js", Kind: LinkScript},
+			},
+			keys: []string{"method", "body", "username", "token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &Page{URL: "http://site.test/dir/page.html"}
+			parseHTML(page, strings.NewReader(tt.html))
+			for _, want := range tt.links {
+				if !hasLink(page.Links, want) {
+					t.Errorf("missing link %+v in %+v", want, page.Links)
+				}
+			}
+			if len(page.Links) != len(tt.links) {
+				t.Errorf("got %d links %+v, want %d", len(page.Links), page.Links, len(tt.links))
+			}
+			if tt.keys != nil && !reflect.DeepEqual(page.JSKeys, tt.keys) {
+				t.Errorf("JSKeys = %v, want %v", page.JSKeys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestParseHTMLForms(t *testing.T) {
+	page := &Page{URL: "http://site.test/account/"}
+	parseHTML(page, strings.NewReader(`
+<form action="update" method="post" enctype="multipart/form-data">
+  <input name="email" type="email" value="a@b.test">
+  <input name="csrf" type="hidden" value="t0k">
+  <input type="submit" value="no name">
+  <textarea name="bio">hello world</textarea>
+  <select name="role"><option value="user">User</option><option value="admin" selected>Admin</option></select>
+  <select name="lang"><option>English</option><option>Deutsch</option></select>
+  <button name="action">Save</button>
+</form>
+<form action="/search"><input name="q"></form>`))
+
+	want := []Form{
+		{
+			Action:  "http://site.test/account/update",
+			Method:  "POST",
+			Enctype: "multipart/form-data",
+			Inputs: []Input{
+				{Name: "email", Type: "email", Default: "a@b.test"},
+				{Name: "csrf", Type: "hidden", Default: "t0k"},
+				{Name: "bio", Type: "textarea", Default: "hello world"},
+				{Name: "role", Type: "select", Default: "admin"},
+				{Name: "lang", Type: "select", Default: "English"},
+				{Name: "action", Type: "submit"},
+			},
+		},
+		{
+			Action:  "http://site.test/search",
+			Method:  "GET",
+			Enctype: defaultEnctype,
+			Inputs:  []Input{{Name: "q", Type: "text"}},
+		},
+	}
+	if !reflect.DeepEqual(page.Forms, want) {
+		t.Errorf("Forms = %+v, want %+v", page.Forms, want)
+	}
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import "sync"
+
+// task 待爬取的页面及其深度
+type task struct {
+	URL   string // 页面URL
+	Depth int    // 页面深度，目标URL为0
+}
+
+// frontier 爬取队列
+// 工作协程处理完一个页面后先把新发现的页面加入队列再标记完成，
+// 因此当队列为空且没有正在处理页面的工作协程时，爬取即结束
+type frontier struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []task
+	busy   int  // 正在处理页面的工作协程数量
+	closed bool // 爬取是否已结束
+}
+
+// newFrontier 创建爬取队列
+func newFrontier() *frontier {
+	f := &frontier{queue: make([]task, 0)}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// push 将页面加入队列，爬取结束后忽略
+func (f *frontier) push(t task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	f.queue = append(f.queue, t)
+	f.cond.Signal()
+}
+
+// pop 取出一个页面并标记工作协程为忙碌，队列为空时阻塞，爬取结束时返回false
+func (f *frontier) pop() (task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.queue) == 0 && !f.closed {
+		f.cond.Wait()
+	}
+	if f.closed {
+		return task{}, false
+	}
+	t := f.queue[0]
+	f.queue = f.queue[1:]
+	f.busy++
+	return t, true
+}
+
+// done 标记一个页面处理完成，队列为空且没有忙碌的工作协程时结束爬取
+func (f *frontier) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.busy--
+	if f.busy == 0 && len(f.queue) == 0 {
+		f.close()
+	}
+}
+
+// close 结束爬取并唤醒所有等待的工作协程，调用方需持有锁
+func (f *frontier) close() {
+	f.closed = true
+	f.cond.Broadcast()
+}
@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxPageSize 读取页面内容的最大长度
+const maxPageSize = 5 << 20
+
+// maxRedirects 单个请求最多跟随的跳转次数
+const maxRedirects = 10
+
+// HTTPEngine 使用标准HTTP客户端的爬取引擎，不依赖浏览器
+type HTTPEngine struct {
+	Client    *http.Client // HTTP客户端
+	UserAgent string       // 请求使用的User-Agent
+	Scope     *Scope       // 爬取范围，跳转到范围外的地址时不跟随，为nil时不限制
+}
+
+// NewHTTPEngine 创建一个新的HTTP爬取引擎
+func NewHTTPEngine(timeout time.Duration) *HTTPEngine {
+	engine := &HTTPEngine{
+		UserAgent: "Mozilla/5.0 (compatible; Nox crawler)",
+	}
+	engine.Client = &http.Client{
+		Timeout:       timeout,
+		CheckRedirect: engine.checkRedirect,
+	}
+	return engine
+}
+
+// checkRedirect 只跟随范围内的跳转，范围外的跳转返回跳转响应本身，由Fetch记录跳转目标
+func (e *HTTPEngine) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if e.Scope != nil {
+		if allowed, _ := e.Scope.Allowed(req.URL); !allowed {
+			return http.ErrUseLastResponse
+		}
+	}
+	return nil
+}
+
+// Fetch 请求页面，HTML页面解析其中的链接，JavaScript文件提取其中的URL
+func (e *HTTPEngine) Fetch(pageURL string) (*Page, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", e.UserAgent)
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	page := &Page{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		Links:       make([]Link, 0),
+	}
+
+	// 发生跳转时最终地址同样作为链接记录
+	if page.URL != pageURL {
+		page.Links = append(page.Links, Link{URL: page.URL, Kind: LinkRedirect})
+	}
+	// 未跟随的范围外跳转，记录跳转目标，由collect标记为范围外
+	if location := resp.Header.Get("Location"); location != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if ref, err := resp.Request.URL.Parse(location); err == nil {
+			page.Links = append(page.Links, Link{URL: ref.String(), Kind: LinkRedirect})
+		}
+	}
+
+	switch {
+	case isJavaScript(page.ContentType, page.URL):
+		for _, link := range analyzeBundle(string(body)) {
+			page.Links = append(page.Links, Link{URL: normalizeURL(link.URL, page.URL), Kind: link.Kind})
+		}
+		page.JSKeys = extractJSONKeysFromJS(string(body))
+	case isHTML(page.ContentType, body):
+		parseHTML(page, bytes.NewReader(body))
+	}
+
+	return page, nil
+}
+
+// Close HTTP引擎无需释放资源
+func (e *HTTPEngine) Close() error {
+	e.Client.CloseIdleConnections()
+	return nil
+}
+
+// isJavaScript 根据内容类型或扩展名判断是否为JavaScript文件
+func isJavaScript(contentType, pageURL string) bool {
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "javascript") || strings.Contains(contentType, "ecmascript") {
+		return true
+	}
+	path, _, _ := strings.Cut(pageURL, "?")
+	return strings.HasSuffix(strings.ToLower(path), ".js") && !strings.Contains(contentType, "html")
+}
+
+// isHTML 根据内容类型或内容判断是否为HTML页面
+func isHTML(contentType string, body []byte) bool {
+	if contentType != "" {
+		return strings.Contains(strings.ToLower(contentType), "html")
+	}
+	return strings.Contains(strings.ToLower(http.DetectContentType(body)), "html")
+}
@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHTTPEngineFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	serve := func(path, contentType, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			} else {
+				// 阻止net/http自动探测并设置内容类型
+				w.Header()["Content-Type"] = nil
+			}
+			w.Write([]byte(body))
+		})
+	}
+	serve("/page", "text/html; charset=utf-8", `<a href="/about">About</a><script>var api = "/api/v1/list"</script>`)
+	serve("/bundle", "application/javascript", `const routes = [{path: "/dashboard"}]; fetch("/api/orders", {body: {orderId: 1}})`)
+	serve("/static/app.js", "text/plain", `location.href = "/logout"`)
+	serve("/missing.js", "text/html", `<a href="/home">Not found</a>`)
+	serve("/sniffed", "", `<!DOCTYPE html><html><a href="/sniffed-link">x</a></html>`)
+	serve("/data.json", "application/json", `{"url": "/not-a-link"}`)
+	mux.Handle("/old", http.RedirectHandler("/page", http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		path  string
+		url   string
+		links []Link
+		keys  []string
+	}{
+		{
+			name: "html",
+			path: "/page",
+			links: []Link{
+				{URL: server.URL + "/about", Kind: LinkAnchor},
+				{URL: server.URL + "/api/v1/list", Kind: LinkJS},
+			},
+		},
+		{
+			name: "javascript content type",
+			path: "/bundle",
+			links: []Link{
+				{URL: server.URL + "/dashboard", Kind: LinkRoute},
+				// 路由同样是字符串，由collect按地址去重时保留前面更具体的来源
+				{URL: server.URL + "/dashboard", Kind: LinkJS},
+				{URL: server.URL + "/api/orders", Kind: LinkJS},
+			},
+			keys: []string{"path", "body", "orderId"},
+		},
+		{
+			name:  "javascript extension",
+			path:  "/static/app.js",
+			links: []Link{{URL: server.URL + "/logout", Kind: LinkJS}},
+			keys:  []string{},
+		},
+		{
+			name:  "html served for script",
+			path:  "/missing.js",
+			links: []Link{{URL: server.URL + "/home", Kind: LinkAnchor}},
+		},
+		{
+			name:  "sniffed html",
+			path:  "/sniffed",
+			links: []Link{{URL: server.URL + "/sniffed-link", Kind: LinkAnchor}},
+		},
+		{
+			name:  "json",
+			path:  "/data.json",
+			links: []Link{},
+		},
+		{
+			name: "redirect",
+			path: "/old",
+			url:  server.URL + "/page",
+			links: []Link{
+				{URL: server.URL + "/page", Kind: LinkRedirect},
+				{URL: server.URL + "/about", Kind: LinkAnchor},
+				{URL: server.URL + "/api/v1/list", Kind: LinkJS},
+			},
+		},
+	}
+
+	engine := NewHTTPEngine(5 * time.Second)
+	defer engine.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := engine.Fetch(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			wantURL := tt.url
+			if wantURL == "" {
+				wantURL = server.URL + tt.path
+			}
+			if page.URL != wantURL || page.StatusCode != http.StatusOK {
+				t.Errorf("page = %s [%d], want %s [200]", page.URL, page.StatusCode, wantURL)
+			}
+			if !reflect.DeepEqual(page.Links, tt.links) {
+				t.Errorf("Links = %+v, want %+v", page.Links, tt.links)
+			}
+			if tt.keys != nil && !reflect.DeepEqual(page.JSKeys, tt.keys) {
+				t.Errorf("JSKeys = %v, want %v", page.JSKeys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestHTTPEngineFetchError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	engine := NewHTTPEngine(time.Second)
+	if _, err := engine.Fetch(server.URL + "/"); err == nil {
+		t.Error("Fetch on a closed server succeeded, want error")
+	}
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// 爬取范围
+const (
+	ScopeHost   = "host"   // 只爬取与目标相同的主机
+	ScopeDomain = "domain" // 爬取与目标属于同一个可注册域名的主机，例如 a.example.com 与 b.example.com
+	ScopeAny    = "any"    // 不限制主机
+)
+
+// defaultDenyExtensions 默认不请求的文件扩展名 (图片、字体、音视频、样式表与压缩包)
+var defaultDenyExtensions = []string{
+	"png", "jpg", "jpeg", "gif", "bmp", "svg", "ico", "webp", "tif", "tiff",
+	"woff", "woff2", "ttf", "eot", "otf",
+	"mp3", "mp4", "avi", "mov", "wmv", "flv", "webm", "wav", "ogg",
+	"css", "zip", "rar", "gz", "tar", "7z", "exe", "dmg", "iso",
+}
+
+// Scope 爬取范围规则，范围外的URL会被记录但不会被请求
+type Scope struct {
+	Mode           string           // 范围 host/domain/any
+	Include        []*regexp.Regexp // 非空时URL必须匹配其中之一
+	Exclude        []*regexp.Regexp // 匹配其中之一的URL不在范围内
+	DenyExtensions map[string]bool  // 不请求的文件扩展名
+
+	host   string // 目标主机 (含端口)
+	domain string // 目标的可注册域名
+}
+
+// NewScope 以目标URL创建默认的爬取范围 (同主机)
+func NewScope(target string) *Scope {
+	scope := &Scope{
+		Mode:           ScopeHost,
+		Include:        make([]*regexp.Regexp, 0),
+		Exclude:        make([]*regexp.Regexp, 0),
+		DenyExtensions: make(map[string]bool),
+	}
+	scope.SetDenyExtensions(defaultDenyExtensions)
+
+	if u, err := url.Parse(target); err == nil {
+		scope.host = strings.ToLower(u.Host)
+		scope.domain = registrableDomain(u.Hostname())
+	}
+	return scope
+}
+
+// SetMode 设置爬取范围 host/domain/any
+func (s *Scope) SetMode(mode string) error {
+	switch mode {
+	case ScopeHost, ScopeDomain, ScopeAny:
+		s.Mode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown crawler scope %q", mode)
+}
+
+// AddInclude 添加URL必须匹配的正则表达式
+func (s *Scope) AddInclude(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid include pattern %q: %v", pattern, err)
+	}
+	s.Include = append(s.Include, re)
+	return nil
+}
+
+// AddExclude 添加需要排除的URL正则表达式
+func (s *Scope) AddExclude(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+	}
+	s.Exclude = append(s.Exclude, re)
+	return nil
+}
+
+// SetDenyExtensions 设置不请求的文件扩展名，替换默认列表
+func (s *Scope) SetDenyExtensions(extensions []string) {
+	s.DenyExtensions = make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext != "" {
+			s.DenyExtensions[ext] = true
+		}
+	}
+}
+
+// Allowed 判断URL是否在爬取范围内，不在范围内时返回原因
+func (s *Scope) Allowed(u *url.URL) (bool, string) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false, "scheme " + u.Scheme
+	}
+
+	switch s.Mode {
+	case ScopeHost:
+		if strings.ToLower(u.Host) != s.host {
+			return false, "external host"
+		}
+	case ScopeDomain:
+		if registrableDomain(u.Hostname()) != s.domain {
+			return false, "external domain"
+		}
+	}
+
+	if ext := strings.TrimPrefix(strings.ToLower(path.Ext(u.Path)), "."); ext != "" && s.DenyExtensions[ext] {
+		return false, "denied extension ." + ext
+	}
+
+	raw := u.String()
+	for _, re := range s.Exclude {
+		if re.MatchString(raw) {
+			return false, "excluded by " + re.String()
+		}
+	}
+	if len(s.Include) > 0 {
+		for _, re := range s.Include {
+			if re.MatchString(raw) {
+				return true, ""
+			}
+		}
+		return false, "not matched by include patterns"
+	}
+
+	return true, ""
+}
+
+// patternKey 返回URL去掉参数值后的模式，例如 ?id=1 与 ?id=2 对应同一个模式
+func patternKey(u *url.URL) string {
+	names := make([]string, 0)
+	for name := range u.Query() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := strings.ToLower(u.Scheme+"://"+u.Host) + u.EscapedPath()
+	if len(names) > 0 {
+		key += "?" + strings.Join(names, "&")
+	}
+	return key
+}
+
+// registrableDomain 返回主机的可注册域名，IP或无法识别时返回主机本身
+func registrableDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
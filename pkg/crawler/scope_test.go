@@ -0,0 +1,231 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestScopeAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		include []string
+		exclude []string
+		url     string
+		allowed bool
+		reason  string
+	}{
+		{name: "host same", mode: ScopeHost, url: "https://www.example.com/a", allowed: true},
+		{name: "host case", mode: ScopeHost, url: "https://WWW.Example.com/a", allowed: true},
+		{name: "host other subdomain", mode: ScopeHost, url: "https://api.example.com/a", reason: "external host"},
+		{name: "host other port", mode: ScopeHost, url: "https://www.example.com:8443/a", reason: "external host"},
+		{name: "domain subdomain", mode: ScopeDomain, url: "https://api.example.com/a", allowed: true},
+		{name: "domain apex", mode: ScopeDomain, url: "http://example.com/", allowed: true},
+		{name: "domain lookalike", mode: ScopeDomain, url: "https://example.com.evil.net/", reason: "external domain"},
+		{name: "domain public suffix", mode: ScopeDomain, url: "https://other.co.uk/", reason: "external domain"},
+		{name: "any external", mode: ScopeAny, url: "https://other.org/a", allowed: true},
+		{name: "any scheme", mode: ScopeAny, url: "ftp://www.example.com/a", reason: "scheme ftp"},
+		{name: "denied extension", mode: ScopeAny, url: "https://www.example.com/logo.PNG", reason: "denied extension .png"},
+		{name: "extension in query", mode: ScopeHost, url: "https://www.example.com/view?file=a.png", allowed: true},
+		{name: "javascript allowed", mode: ScopeHost, url: "https://www.example.com/app.js", allowed: true},
+		{name: "include matched", mode: ScopeHost, include: []string{`/api/`, `/admin/`}, url: "https://www.example.com/admin/users", allowed: true},
+		{name: "include missed", mode: ScopeHost, include: []string{`/api/`}, url: "https://www.example.com/blog", reason: "not matched by include patterns"},
+		{name: "exclude", mode: ScopeHost, exclude: []string{`logout`}, url: "https://www.example.com/logout", reason: "excluded by logout"},
+		{name: "exclude wins over include", mode: ScopeHost, include: []string{`/api/`}, exclude: []string{`delete`}, url: "https://www.example.com/api/delete?id=1", reason: "excluded by delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := NewScope("https://www.example.com/index")
+			if err := scope.SetMode(tt.mode); err != nil {
+				t.Fatalf("SetMode: %v", err)
+			}
+			for _, pattern := range tt.include {
+				if err := scope.AddInclude(pattern); err != nil {
+					t.Fatalf("AddInclude: %v", err)
+				}
+			}
+			for _, pattern := range tt.exclude {
+				if err := scope.AddExclude(pattern); err != nil {
+					t.Fatalf("AddExclude: %v", err)
+				}
+			}
+
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			allowed, reason := scope.Allowed(u)
+			if allowed != tt.allowed || reason != tt.reason {
+				t.Errorf("Allowed(%s) = %v %q, want %v %q", tt.url, allowed, reason, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestScopeSetters(t *testing.T) {
+	scope := NewScope("http://127.0.0.1:8080/")
+	if err := scope.SetMode("everything"); err == nil {
+		t.Error("SetMode accepted an unknown mode")
+	}
+	if err := scope.AddInclude("("); err == nil {
+		t.Error("AddInclude accepted an invalid pattern")
+	}
+	if err := scope.AddExclude("["); err == nil {
+		t.Error("AddExclude accepted an invalid pattern")
+	}
+
+	// 自定义列表替换默认列表，并忽略大小写、点号与空白
+	scope.SetDenyExtensions([]string{" .PDF", "", "docx"})
+	for raw, want := range map[string]bool{
+		"http://127.0.0.1:8080/a.pdf":  false,
+		"http://127.0.0.1:8080/a.docx": false,
+		"http://127.0.0.1:8080/a.png":  true,
+	} {
+		u, _ := url.Parse(raw)
+		if allowed, _ := scope.Allowed(u); allowed != want {
+			t.Errorf("Allowed(%s) = %v, want %v", raw, allowed, want)
+		}
+	}
+}
+
+func TestPatternKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"http://h/item?id=1", "http://h/item?id=2", true},
+		{"http://h/item?id=1&page=2", "http://h/item?page=9&id=3", true},
+		{"HTTP://H/item?id=1", "http://h/item?id=1", true},
+		{"http://h/item?id=1", "http://h/item?name=1", false},
+		{"http://h/item?id=1", "http://h/item", false},
+		{"http://h/item?id=1", "http://h/other?id=1", false},
+		{"http://h/Item", "http://h/item", false},
+		{"http://h/item", "https://h/item", false},
+	}
+	for _, tt := range tests {
+		a, _ := url.Parse(tt.a)
+		b, _ := url.Parse(tt.b)
+		if same := patternKey(a) == patternKey(b); same != tt.same {
+			t.Errorf("patternKey(%s) == patternKey(%s) is %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+// TestCrawlScope 范围外、扩展名被拒绝与参数模式重复的页面只记录不请求，跳转到范围外时不跟随
+func TestCrawlScope(t *testing.T) {
+	external, externalCounter := newLinkServer(func(path string) []string { return nil })
+	defer external.Close()
+
+	counter := &hitCounter{hits: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.add(r.URL.Path)
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			for _, link := range []string{"/item?id=1", "/item?id=2", "/logo.png", "/away", external.URL + "/direct"} {
+				fmt.Fprintf(w, `<a href="%s">%s</a>`, link, link)
+			}
+		case "/away":
+			http.Redirect(w, r, external.URL+"/target", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL+"/")
+	c.SetDepth(2)
+	results := crawlWithDeadline(t, c)
+
+	if got := counter.get("/item"); got != 1 {
+		t.Errorf("/item fetched %d times, want 1", got)
+	}
+	if got := counter.get("/logo.png"); got != 0 {
+		t.Errorf("/logo.png fetched %d times, want 0", got)
+	}
+	if got := externalCounter.total(); got != 0 {
+		t.Errorf("external server fetched %d times, want 0", got)
+	}
+
+	for _, tt := range []struct {
+		url     string
+		inScope bool
+	}{
+		{server.URL + "/item?id=1", true},
+		{server.URL + "/logo.png", false},
+		{external.URL + "/direct", false},
+		{external.URL + "/target", false},
+	} {
+		result := findResult(results, tt.url)
+		if result == nil {
+			t.Errorf("%s not in results", tt.url)
+			continue
+		}
+		if result.InScope != tt.inScope {
+			t.Errorf("%s InScope = %v (%s), want %v", tt.url, result.InScope, result.Reason, tt.inScope)
+		}
+	}
+}
+
+// TestCrawlMaxPages 串行爬取时恰好请求MaxPages个页面，其余页面只记录
+func TestCrawlMaxPages(t *testing.T) {
+	server, counter := newLinkServer(func(path string) []string {
+		if path == "/" {
+			return []string{"/1", "/2", "/3", "/4", "/5"}
+		}
+		return nil
+	})
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL+"/")
+	c.SetDepth(3)
+	c.SetConcurrent(1)
+	c.SetMaxPages(3)
+	results := crawlWithDeadline(t, c)
+
+	if got := counter.total(); got != 3 {
+		t.Errorf("fetched %d pages, want 3", got)
+	}
+	if got := len(results); got != 5 {
+		t.Errorf("got %d results, want all 5 links recorded", got)
+	}
+}
+
+// TestHTTPEngineRedirectScope 范围外的跳转不跟随，跳转目标作为链接返回
+func TestHTTPEngineRedirectScope(t *testing.T) {
+	external, externalCounter := newLinkServer(func(path string) []string { return nil })
+	defer external.Close()
+	server := httptest.NewServer(http.RedirectHandler(external.URL+"/landing", http.StatusMovedPermanently))
+	defer server.Close()
+
+	engine := NewHTTPEngine(5 * time.Second)
+	engine.Scope = NewScope(server.URL + "/")
+	defer engine.Close()
+
+	page, err := engine.Fetch(server.URL + "/old")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if page.StatusCode != http.StatusMovedPermanently || page.URL != server.URL+"/old" {
+		t.Errorf("page = %s [%d], want the unfollowed redirect", page.URL, page.StatusCode)
+	}
+	if !hasLink(page.Links, Link{URL: external.URL + "/landing", Kind: LinkRedirect}) {
+		t.Errorf("Links = %+v, want redirect target", page.Links)
+	}
+	if got := externalCounter.total(); got != 0 {
+		t.Errorf("external server fetched %d times, want 0", got)
+	}
+
+	// 未设置范围时正常跟随
+	engine.Scope = nil
+	page, err = engine.Fetch(server.URL + "/old")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if page.URL != external.URL+"/landing" || externalCounter.total() != 1 {
+		t.Errorf("page = %s, external hits %d, want the redirect followed", page.URL, externalCounter.total())
+	}
+}
@@ -0,0 +1,239 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 敏感信息风险等级
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+	SeverityInfo   = "info"
+)
+
+// maxSecretLength 记录的敏感信息最大长度，私钥等较长内容会被截断
+const maxSecretLength = 120
+
+// SecretRule 敏感信息检测规则
+type SecretRule struct {
+	Name       string            // 规则名称
+	Severity   string            // 风险等级
+	Pattern    *regexp.Regexp    // 匹配的正则表达式
+	Group      int               // 作为敏感信息值的捕获组，0表示整个匹配
+	MinEntropy float64           // 值的最小香农熵，用于过滤通用规则中的普通单词，短值按长度降低要求，0表示不检查
+	Validate   func(string) bool // 额外的校验函数，为空时不校验
+}
+
+// Secret 在页面内容中发现的敏感信息
+type Secret struct {
+	Rule     string // 规则名称
+	Severity string // 风险等级
+	Value    string // 匹配到的值
+	URL      string // 所在页面或脚本的地址
+	Line     int    // 所在行号，从1开始
+}
+
+// defaultSecretAllowlist 默认忽略的示例值与占位符
+var defaultSecretAllowlist = []string{
+	`(?i)example|sample|dummy|placeholder|your[_-]|xxxx|redacted|changeme`,
+	`\$\{|\{\{|<%`,
+	`(?i)@\dx\.(?:png|jpe?g|gif|svg|webp)$`,
+}
+
+// DefaultSecretRules 返回内置的敏感信息检测规则
+func DefaultSecretRules() []*SecretRule {
+	return []*SecretRule{
+		{Name: "AWS Access Key ID", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), Group: 1},
+		{Name: "AWS Secret Access Key", Severity: SeverityHigh, Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?["']([0-9A-Za-z/+]{40})["']`), Group: 1, MinEntropy: 4.0},
+		{Name: "Aliyun AccessKey ID", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b(LTAI[0-9A-Za-z]{12,20})\b`), Group: 1},
+		{Name: "Tencent Cloud SecretId", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b(AKID[0-9A-Za-z]{32})\b`), Group: 1},
+		{Name: "Google API Key", Severity: SeverityMedium, Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})`), Group: 1},
+		{Name: "GitHub Token", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b(gh[pousr]_[0-9A-Za-z]{36,255})\b`), Group: 1},
+		{Name: "Slack Token", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b(xox[abprs]-[0-9A-Za-z-]{10,72})`), Group: 1},
+		{Name: "Stripe Secret Key", Severity: SeverityHigh, Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,99})\b`), Group: 1},
+		{Name: "Private Key", Severity: SeverityHigh, Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+		{Name: "JSON Web Token", Severity: SeverityMedium, Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`), Group: 1},
+		{Name: "Generic Secret", Severity: SeverityMedium, Pattern: regexp.MustCompile(`(?i)(?:api[_-]?key|secret[_-]?key|client[_-]?secret|access[_-]?token|auth[_-]?token|password|passwd)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`), Group: 1, MinEntropy: 3.5},
+		{Name: "Internal IP Address", Severity: SeverityLow, Pattern: regexp.MustCompile(`\b(10\.\d{1,3}\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3})\b`), Group: 1, Validate: validIP},
+		{Name: "Email Address", Severity: SeverityInfo, Pattern: regexp.MustCompile(`\b([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`), Group: 1},
+		{Name: "Java Stack Trace", Severity: SeverityLow, Pattern: regexp.MustCompile(`\bat [\w$.]+\([\w$]+\.java:\d+\)`)},
+		{Name: "Python Traceback", Severity: SeverityLow, Pattern: regexp.MustCompile(`Traceback \(most recent call last\)`)},
+		{Name: "PHP Error", Severity: SeverityLow, Pattern: regexp.MustCompile(`(?i)(?:Fatal error|Parse error|Warning|Notice)(?:</b>)?:.{1,200}? on line (?:<b>)?\d+`)},
+		{Name: ".NET Exception", Severity: SeverityLow, Pattern: regexp.MustCompile(`Server Error in '[^']*' Application|\bSystem\.[\w.]+Exception\b: `)},
+		{Name: "Go Panic", Severity: SeverityLow, Pattern: regexp.MustCompile(`goroutine \d+ \[running\]:`)},
+	}
+}
+
+// secretScanner 按规则扫描页面内容，并对结果去重
+type secretScanner struct {
+	rules     []*SecretRule
+	allowlist []*regexp.Regexp
+
+	mu      sync.Mutex
+	seen    map[string]bool
+	secrets []Secret
+}
+
+// newSecretScanner 创建敏感信息扫描器
+func newSecretScanner(rules []*SecretRule, allowlist []*regexp.Regexp) *secretScanner {
+	return &secretScanner{
+		rules:     rules,
+		allowlist: allowlist,
+		seen:      make(map[string]bool),
+		secrets:   make([]Secret, 0),
+	}
+}
+
+// scan 扫描页面内容，返回新发现的敏感信息
+func (s *secretScanner) scan(content, pageURL string) []Secret {
+	found := make([]Secret, 0)
+	// 同一位置的值只由靠前的 (更具体的) 规则报告一次
+	reported := make(map[int]bool)
+	for _, rule := range s.rules {
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(content, -1) {
+			if 2*rule.Group+1 >= len(match) || match[2*rule.Group] < 0 {
+				continue
+			}
+			start, end := match[2*rule.Group], match[2*rule.Group+1]
+			value := content[start:end]
+			if reported[start] {
+				continue
+			}
+
+			if rule.MinEntropy > 0 && entropy(value) < entropyThreshold(rule.MinEntropy, value) {
+				continue
+			}
+			if rule.Validate != nil && !rule.Validate(value) {
+				continue
+			}
+			if s.allowed(value) {
+				continue
+			}
+
+			if len(value) > maxSecretLength {
+				value = value[:maxSecretLength] + "..."
+			}
+			secret := Secret{
+				Rule:     rule.Name,
+				Severity: rule.Severity,
+				Value:    value,
+				URL:      pageURL,
+				Line:     strings.Count(content[:start], "\n") + 1,
+			}
+			reported[start] = true
+			if s.add(secret) {
+				found = append(found, secret)
+			}
+		}
+	}
+	return found
+}
+
+// allowed 判断值是否在忽略列表中
+func (s *secretScanner) allowed(value string) bool {
+	for _, re := range s.allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// add 记录敏感信息，同一地址中相同规则的相同值只记录一次
+func (s *secretScanner) add(secret Secret) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := secret.Rule + "\x00" + secret.Value + "\x00" + secret.URL
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	s.secrets = append(s.secrets, secret)
+	return true
+}
+
+// list 返回按风险等级、规则与地址排序的敏感信息
+func (s *secretScanner) list() []Secret {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rank := map[string]int{SeverityHigh: 0, SeverityMedium: 1, SeverityLow: 2, SeverityInfo: 3}
+	secrets := append([]Secret(nil), s.secrets...)
+	sort.SliceStable(secrets, func(i, j int) bool {
+		if rank[secrets[i].Severity] != rank[secrets[j].Severity] {
+			return rank[secrets[i].Severity] < rank[secrets[j].Severity]
+		}
+		if secrets[i].Rule != secrets[j].Rule {
+			return secrets[i].Rule < secrets[j].Rule
+		}
+		return secrets[i].URL < secrets[j].URL
+	})
+	return secrets
+}
+
+// compileAllowlist 编译忽略列表中的正则表达式
+func compileAllowlist(patterns []string) ([]*regexp.Regexp, error) {
+	allowlist := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %v", pattern, err)
+		}
+		allowlist = append(allowlist, re)
+	}
+	return allowlist, nil
+}
+
+// entropy 计算字符串的香农熵 (每个字符的比特数)
+func entropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+
+	result := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		result -= p * math.Log2(p)
+	}
+	return result
+}
+
+// entropyThreshold 返回值需要达到的最小熵
+// n个字符的熵最大为log2(n)，固定阈值会使短值永远无法匹配，因此阈值不超过最大熵的90%
+func entropyThreshold(minEntropy float64, value string) float64 {
+	return math.Min(minEntropy, 0.9*math.Log2(float64(utf8.RuneCountInString(value))))
+}
+
+// validIP 判断是否为合法的IP地址，过滤 10.0.300.1 这类版本号
+func validIP(value string) bool {
+	return net.ParseIP(value) != nil
+}
+
+// isText 根据内容类型判断页面内容是否为文本，未知类型按文本处理
+func isText(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if contentType == "" {
+		return true
+	}
+	for _, kind := range []string{"text/", "javascript", "ecmascript", "json", "xml"} {
+		if strings.Contains(contentType, kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 种子来源
+const (
+	LinkRobots    = "robots"     // robots.txt中的Allow/Disallow路径
+	LinkSitemap   = "sitemap"    // sitemap.xml及sitemap索引中的地址
+	LinkWellKnown = "well-known" // .well-known目录下的文件及其中的地址
+)
+
+// maxSitemaps 最多解析的sitemap文件数量，防止sitemap索引循环引用
+const maxSitemaps = 20
+
+// maxSitemapURLs 最多从sitemap中读取的地址数量
+const maxSitemapURLs = 5000
+
+// wellKnownPaths 尝试获取的 .well-known 文件
+var wellKnownPaths = []string{
+	"/.well-known/security.txt",
+	"/security.txt",
+	"/.well-known/openid-configuration",
+	"/.well-known/oauth-authorization-server",
+	"/.well-known/change-password",
+	"/.well-known/assetlinks.json",
+	"/.well-known/apple-app-site-association",
+}
+
+// Finding 爬取过程中发现的值得关注的信息，例如robots.txt中禁止爬取的路径
+type Finding struct {
+	Severity string // 风险等级
+	Source   string // 来源 robots/sitemap/well-known
+	Title    string // 描述
+	URL      string // 相关地址
+}
+
+// sitemapDocument sitemap文件，urlset与sitemapindex共用同一结构
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+// sitemapLoc sitemap中的地址
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// seed 从robots.txt、sitemap与 .well-known 文件中收集初始链接，并记录值得关注的信息
+func (c *Crawler) seed() []*CrawlResult {
+	base, err := url.Parse(c.Target)
+	if err != nil {
+		return nil
+	}
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/"}
+
+	seeds := make([]*CrawlResult, 0)
+	add := func(raw, kind, parent string) {
+		if resolved := normalizeURL(raw, parent); resolved != "" {
+			seeds = append(seeds, &CrawlResult{URL: resolved, Depth: 1, ParentURL: parent, Kind: kind})
+		}
+	}
+
+	// robots.txt
+	robotsURL := root.ResolveReference(&url.URL{Path: "/robots.txt"}).String()
+	sitemaps := []string{root.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()}
+	if body, ok := c.fetchSeed(robotsURL); ok {
+		allows, disallows, listed := parseRobots(body)
+		for _, path := range allows {
+			add(path, LinkRobots, robotsURL)
+		}
+		for _, path := range disallows {
+			add(path, LinkRobots, robotsURL)
+			c.addFinding(Finding{SeverityInfo, LinkRobots, "Disallowed path in robots.txt", normalizeURL(path, robotsURL)})
+		}
+		sitemaps = append(sitemaps, listed...)
+	}
+
+	// sitemap.xml与sitemap索引
+	for _, loc := range c.sitemapURLs(sitemaps) {
+		add(loc, LinkSitemap, root.String())
+	}
+
+	// .well-known 文件
+	for _, path := range wellKnownPaths {
+		fileURL := root.ResolveReference(&url.URL{Path: path}).String()
+		body, ok := c.fetchSeed(fileURL)
+		if !ok {
+			continue
+		}
+		add(fileURL, LinkWellKnown, root.String())
+		c.addFinding(Finding{SeverityInfo, LinkWellKnown, fmt.Sprintf("Found %s", path), fileURL})
+		for _, raw := range wellKnownURLs(body) {
+			add(raw, LinkWellKnown, fileURL)
+		}
+	}
+
+	return seeds
+}
+
+// fetchSeed 获取种子文件，只接受200状态码的非HTML响应，避免把软404页面当作种子文件
+func (c *Crawler) fetchSeed(fileURL string) ([]byte, bool) {
+	resp, err := c.client.Get(fileURL)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+		return nil, false
+	}
+
+	var reader io.Reader = io.LimitReader(resp.Body, maxPageSize)
+	if strings.HasSuffix(resp.Request.URL.Path, ".gz") {
+		if gz, err := gzip.NewReader(reader); err == nil {
+			defer gz.Close()
+			reader = io.LimitReader(gz, maxPageSize)
+		}
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil, false
+	}
+	return body, true
+}
+
+// sitemapURLs 依次解析sitemap文件，sitemap索引中的子sitemap加入待解析列表
+// robots.txt与sitemap索引中的地址可能指向其他站点，范围外的sitemap不请求
+func (c *Crawler) sitemapURLs(sitemaps []string) []string {
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	for i := 0; i < len(sitemaps) && i < maxSitemaps && len(urls) < maxSitemapURLs; i++ {
+		if seen[sitemaps[i]] {
+			continue
+		}
+		seen[sitemaps[i]] = true
+		if !c.seedAllowed(sitemaps[i]) {
+			c.Logger.Info(fmt.Sprintf("Skip out-of-scope sitemap %s", sitemaps[i]))
+			continue
+		}
+
+		body, ok := c.fetchSeed(sitemaps[i])
+		if !ok {
+			continue
+		}
+		document := &sitemapDocument{}
+		if err := xml.Unmarshal(body, document); err != nil {
+			c.Logger.Warnning(fmt.Sprintf("Failed to parse sitemap %s: %v", sitemaps[i], err))
+			continue
+		}
+		for _, entry := range document.Sitemaps {
+			if loc := strings.TrimSpace(entry.Loc); loc != "" {
+				sitemaps = append(sitemaps, loc)
+			}
+		}
+		for _, entry := range document.URLs {
+			if loc := strings.TrimSpace(entry.Loc); loc != "" && len(urls) < maxSitemapURLs {
+				urls = append(urls, loc)
+			}
+		}
+	}
+	return urls
+}
+
+// seedAllowed 判断种子文件地址是否在爬取范围内
+func (c *Crawler) seedAllowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if c.Scope == nil {
+		return true
+	}
+	allowed, _ := c.Scope.Allowed(u)
+	return allowed
+}
+
+// addFinding 记录值得关注的信息
+func (c *Crawler) addFinding(finding Finding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.findings = append(c.findings, finding)
+}
+
+// parseRobots 解析robots.txt，返回所有User-agent下的Allow、Disallow路径与Sitemap地址
+// 路径中的通配符 * 与结尾标记 $ 会被截断，例如 /admin/*.php 转换为 /admin/
+func parseRobots(body []byte) ([]string, []string, []string) {
+	allows, disallows, sitemaps := make([]string, 0), make([]string, 0), make([]string, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		if field == "sitemap" {
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+			continue
+		}
+		if field != "allow" && field != "disallow" {
+			continue
+		}
+
+		if i := strings.IndexAny(value, "*$"); i >= 0 {
+			value = value[:i]
+		}
+		if !strings.HasPrefix(value, "/") || seen[field+value] {
+			continue
+		}
+		seen[field+value] = true
+		if field == "allow" {
+			allows = append(allows, value)
+		} else {
+			disallows = append(disallows, value)
+		}
+	}
+	return allows, disallows, sitemaps
+}
+
+// wellKnownURLs 提取 .well-known 文件中的地址
+// security.txt按行解析 Contact、Policy 等字段，JSON文件提取所有http(s)字符串值
+func wellKnownURLs(body []byte) []string {
+	urls := make([]string, 0)
+
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err == nil {
+		collectJSONURLs(document, &urls)
+		return urls
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		_, value, ok := strings.Cut(scanner.Text(), ":")
+		value = strings.TrimSpace(value)
+		if ok && (strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")) {
+			urls = append(urls, value)
+		}
+	}
+	return urls
+}
+
+// collectJSONURLs 递归收集JSON中的http(s)字符串
+func collectJSONURLs(value interface{}, urls *[]string) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			*urls = append(*urls, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectJSONURLs(item, urls)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			collectJSONURLs(item, urls)
+		}
+	}
+}
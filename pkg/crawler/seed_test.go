@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSeedSitemapScope robots.txt与sitemap索引中指向范围外的sitemap不被请求
+func TestSeedSitemapScope(t *testing.T) {
+	var external int64
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&external, 1)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<urlset><url><loc>http://other.invalid/leak</loc></url></urlset>`))
+	}))
+	defer other.Close()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html></html>`))
+	})
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "User-agent: *\nDisallow: /admin\nSitemap: %s/robots-sitemap.xml\n", other.URL)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/pages.xml</loc></sitemap><sitemap><loc>%s/index-sitemap.xml</loc></sitemap></sitemapindex>`, server.URL, other.URL)
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<urlset><url><loc>%s/about</loc></url></urlset>`, server.URL)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL+"/")
+	c.SetSeed(true)
+	c.SetDepth(1)
+	results := c.Crawl()
+
+	if about := findResult(results, server.URL+"/about"); about == nil || about.Kind != LinkSitemap {
+		t.Errorf("sitemap result = %+v, want kind %s", about, LinkSitemap)
+	}
+	if admin := findResult(results, server.URL+"/admin"); admin == nil || admin.Kind != LinkRobots {
+		t.Errorf("robots result = %+v, want kind %s", admin, LinkRobots)
+	}
+	if hits := atomic.LoadInt64(&external); hits != 0 {
+		t.Errorf("off-scope sitemaps fetched %d times, want 0", hits)
+	}
+}
@@ -0,0 +1,236 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSourceMapSize 读取source map文件的最大长度
+const maxSourceMapSize = 20 << 20
+
+// sourceMappingPattern 脚本末尾的source map注释，例如 //# sourceMappingURL=app.js.map
+var sourceMappingPattern = regexp.MustCompile(`(?m)^[ \t]*//[#@][ \t]*sourceMappingURL=(\S+)[ \t]*$`)
+
+// SourceMap source map文件中与源代码还原相关的字段
+type SourceMap struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+}
+
+// SourceFile source map中包含内容的源文件
+type SourceFile struct {
+	Name    string // source map中的原始名称
+	Path    string // 清理后的相对路径，不含 .. 与绝对路径
+	Content string // 源代码
+}
+
+// sourceMappingURL 返回脚本中最后一个source map注释指向的地址
+func sourceMappingURL(jsCode string) string {
+	matches := sourceMappingPattern.FindAllStringSubmatch(jsCode, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// loadSourceMap 获取并解析source map，支持 data: 内联的source map
+func loadSourceMap(client *http.Client, mapURL string) (*SourceMap, error) {
+	var data []byte
+	if strings.HasPrefix(mapURL, "data:") {
+		meta, payload, ok := strings.Cut(strings.TrimPrefix(mapURL, "data:"), ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid inline source map")
+		}
+		if strings.HasSuffix(meta, ";base64") {
+			decoded, err := base64.StdEncoding.DecodeString(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode inline source map: %v", err)
+			}
+			data = decoded
+		} else {
+			unescaped, err := url.PathUnescape(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode inline source map: %v", err)
+			}
+			data = []byte(unescaped)
+		}
+	} else {
+		resp, err := client.Get(mapURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch source map: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch source map: status %d", resp.StatusCode)
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxSourceMapSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source map: %v", err)
+		}
+	}
+
+	// 部分source map以 )]}' 开头防止被当作脚本执行
+	data = []byte(strings.TrimPrefix(string(data), ")]}'"))
+
+	sourceMap := &SourceMap{}
+	if err := json.Unmarshal(data, sourceMap); err != nil {
+		return nil, fmt.Errorf("failed to parse source map: %v", err)
+	}
+	return sourceMap, nil
+}
+
+// Files 返回source map中包含内容的源文件
+func (m *SourceMap) Files() []SourceFile {
+	files := make([]SourceFile, 0)
+	seen := make(map[string]bool)
+	for i, name := range m.Sources {
+		if i >= len(m.SourcesContent) || m.SourcesContent[i] == nil {
+			continue
+		}
+
+		full := name
+		if m.SourceRoot != "" && !strings.Contains(name, "://") {
+			full = strings.TrimSuffix(m.SourceRoot, "/") + "/" + name
+		}
+		cleaned := cleanSourcePath(full)
+		if cleaned == "" || seen[cleaned] {
+			continue
+		}
+		seen[cleaned] = true
+		files = append(files, SourceFile{Name: name, Path: cleaned, Content: *m.SourcesContent[i]})
+	}
+	return files
+}
+
+// cleanSourcePath 将source map中的源文件名转换为安全的相对路径
+// 例如 webpack:///./src/App.vue 转换为 src/App.vue，../../etc/passwd 转换为 etc/passwd
+func cleanSourcePath(name string) string {
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name, _, _ = strings.Cut(name, "?")
+	name = strings.ReplaceAll(name, "\\", "/")
+	// 去掉Windows盘符，例如 C:/project/src/a.js
+	if len(name) >= 2 && name[1] == ':' {
+		name = name[2:]
+	}
+
+	cleaned := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if cleaned == "" || cleaned == "." {
+		return ""
+	}
+	return cleaned
+}
+
+// writeSourceFiles 将源文件写入 dir/主机名 目录，返回写入的文件数量
+// 写入前确认目标路径位于输出目录内，防止目录穿越
+func writeSourceFiles(dir, host string, files []SourceFile) (int, error) {
+	root, err := filepath.Abs(filepath.Join(dir, strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(host)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve output directory: %v", err)
+	}
+
+	written := 0
+	for _, file := range files {
+		target := filepath.Join(root, filepath.FromSlash(file.Path))
+		rel, err := filepath.Rel(root, target)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return written, fmt.Errorf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(file.Content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write source file: %v", err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// sourceMapLinks 获取脚本的source map，还原源代码到输出目录，并分析源代码中的接口、路由与敏感信息
+func (c *Crawler) sourceMapLinks(page *Page) []Link {
+	raw := sourceMappingURL(string(page.Body))
+	if raw == "" {
+		return nil
+	}
+	mapURL := raw
+	if !strings.HasPrefix(raw, "data:") {
+		mapURL = normalizeURL(raw, page.URL)
+		// 范围外的source map不请求，只作为结果记录，由collect标记为范围外
+		u, err := url.Parse(mapURL)
+		if err != nil || mapURL == "" {
+			return nil
+		}
+		if c.Scope != nil {
+			if allowed, _ := c.Scope.Allowed(u); !allowed {
+				return []Link{{URL: mapURL, Kind: LinkSourceMap}}
+			}
+		}
+	}
+
+	sourceMap, err := loadSourceMap(c.client, mapURL)
+	if err != nil {
+		c.Logger.Warnning(fmt.Sprintf("Failed to load source map of %s: %v", page.URL, err))
+		return nil
+	}
+	files := sourceMap.Files()
+	c.Logger.Success(fmt.Sprintf("Found source map of %s (%d sources)", page.URL, len(files)))
+
+	if c.SourceMapDir != "" {
+		host := ""
+		if u, err := url.Parse(page.URL); err == nil {
+			host = u.Host
+		}
+		written, err := writeSourceFiles(c.SourceMapDir, host, files)
+		if err != nil {
+			c.Logger.Warnning(fmt.Sprintf("Failed to restore sources of %s: %v", page.URL, err))
+		} else {
+			c.Logger.Info(fmt.Sprintf("Restored %d source files of %s to %s", written, page.URL, c.SourceMapDir))
+		}
+	}
+
+	// 内联的source map以脚本地址作为源文件的位置
+	links := make([]Link, 0)
+	origin := page.URL
+	if !strings.HasPrefix(mapURL, "data:") {
+		origin = mapURL
+		links = append(links, Link{URL: mapURL, Kind: LinkSourceMap})
+	}
+	for _, file := range files {
+		if isVendorSource(file.Path) {
+			continue
+		}
+		for _, link := range analyzeBundle(file.Content) {
+			if link.Kind == LinkJS {
+				link.Kind = LinkSource
+			}
+			links = append(links, link)
+		}
+		c.endpoints.addJSKeys(extractJSONKeysFromJS(file.Content), page.URL)
+		if c.Secrets {
+			for _, secret := range c.secrets.scan(file.Content, origin+"#"+file.Path) {
+				c.Logger.Warnning(fmt.Sprintf("Found %s [%s]: %s (%s:%d)", secret.Rule, secret.Severity, secret.Value, secret.URL, secret.Line))
+			}
+		}
+	}
+	return links
+}
+
+// isVendorSource 判断源文件是否为第三方依赖或打包工具生成的代码，这些文件不做接口分析
+func isVendorSource(sourcePath string) bool {
+	return strings.Contains(sourcePath, "node_modules/") || strings.HasPrefix(sourcePath, "webpack/") || strings.Contains(sourcePath, "(webpack)")
+}
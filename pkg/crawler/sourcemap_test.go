@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSourceMapScope 范围内的source map被请求并分析，范围外的只记录不请求
+func TestSourceMapScope(t *testing.T) {
+	var external int64
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&external, 1)
+		w.Write([]byte(`{"version":3,"sources":[],"sourcesContent":[]}`))
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><script src="/app.js"></script><script src="/vendor.js"></script></html>`))
+	})
+	mux.HandleFunc("/app.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte("var a=1;\n//# sourceMappingURL=app.js.map\n"))
+	})
+	mux.HandleFunc("/app.js.map", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":3,"sources":["webpack:///./src/api.js"],"sourcesContent":["fetch(\"/api/users\")"]}`))
+	})
+	mux.HandleFunc("/vendor.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		fmt.Fprintf(w, "var b=2;\n//# sourceMappingURL=%s/vendor.js.map\n", other.URL)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL+"/")
+	results := c.Crawl()
+
+	inScope := findResult(results, server.URL+"/app.js.map")
+	if inScope == nil || inScope.Kind != LinkSourceMap || !inScope.InScope {
+		t.Errorf("in-scope source map result = %+v, want in-scope %s", inScope, LinkSourceMap)
+	}
+	if source := findResult(results, server.URL+"/api/users"); source == nil || source.Kind != LinkSource {
+		t.Errorf("route from restored source = %+v, want kind %s", source, LinkSource)
+	}
+
+	offScope := findResult(results, other.URL+"/vendor.js.map")
+	if offScope == nil || offScope.Kind != LinkSourceMap || offScope.InScope {
+		t.Errorf("off-scope source map result = %+v, want off-scope %s", offScope, LinkSourceMap)
+	}
+	if hits := atomic.LoadInt64(&external); hits != 0 {
+		t.Errorf("off-scope source map fetched %d times, want 0", hits)
+	}
+}
+
+func TestCleanSourcePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"webpack:///./src/App.vue", "src/App.vue"},
+		{"webpack:///src/main.js?5d3f", "src/main.js"},
+		{"src/api/user.js?v=2#L1", "src/api/user.js"},
+		{"../../etc/passwd", "etc/passwd"},
+		{"webpack://app/../../../root/.ssh/id_rsa", "root/.ssh/id_rsa"},
+		{"/etc/passwd", "etc/passwd"},
+		{`C:\Users\dev\app\src\index.js`, "Users/dev/app/src/index.js"},
+		{"C:/project/src/a.js", "project/src/a.js"},
+		{`src\..\..\..\secret.txt`, "secret.txt"},
+		{"", ""},
+		{".", ""},
+		{"webpack:///", ""},
+		{"../..", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanSourcePath(tt.name); got != tt.want {
+			t.Errorf("cleanSourcePath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSourceMapFiles(t *testing.T) {
+	content := func(s string) *string { return &s }
+	sourceMap := &SourceMap{
+		SourceRoot: "webpack:///",
+		Sources:    []string{"./src/a.js", "src/a.js?v=1", "../src/a.js", "src/b.js", "src/c.js", "src/d.js"},
+		SourcesContent: []*string{
+			content("a"), content("a again"), content("a escaped"), nil, content("c"),
+		},
+	}
+
+	files := sourceMap.Files()
+	got := make([]string, 0)
+	for _, file := range files {
+		got = append(got, file.Path+"="+file.Content)
+	}
+	want := "src/a.js=a,src/c.js=c"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Files() = %v, want %s", got, want)
+	}
+	if files[0].Name != "./src/a.js" {
+		t.Errorf("Files()[0].Name = %q, want the original name", files[0].Name)
+	}
+}
+
+// TestWriteSourceFiles 即使传入未经清理的路径，也不会在输出目录之外写入文件
+func TestWriteSourceFiles(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "out")
+
+	files := []SourceFile{
+		{Path: "src/App.vue", Content: "<template/>"},
+		{Path: "../escape.js", Content: "x"},
+		{Path: "../../escape.js", Content: "x"},
+		{Path: "src/../../../escape.js", Content: "x"},
+		{Path: ".", Content: "x"},
+		{Path: "/abs/inside.js", Content: "inside"},
+	}
+	written, err := writeSourceFiles(dir, "../evil:8080", files)
+	if err != nil {
+		t.Fatalf("writeSourceFiles: %v", err)
+	}
+	if written != 2 {
+		t.Errorf("written = %d, want 2", written)
+	}
+
+	root := filepath.Join(dir, ".._evil_8080")
+	found := make([]string, 0)
+	filepath.Walk(base, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			found = append(found, p)
+		}
+		return nil
+	})
+	sort.Strings(found)
+	want := []string{
+		filepath.Join(root, "abs", "inside.js"),
+		filepath.Join(root, "src", "App.vue"),
+	}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Errorf("files on disk = %v, want %v", found, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "src", "App.vue"))
+	if err != nil || string(data) != "<template/>" {
+		t.Errorf("App.vue = %q, %v", data, err)
+	}
+}
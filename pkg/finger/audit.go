@@ -0,0 +1,278 @@
+package finger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 安全问题风险等级
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+	SeverityInfo   = "info"
+)
+
+// minHSTSMaxAge HSTS建议的最小有效期 (180天)
+const minHSTSMaxAge = 15552000
+
+// corsTestOrigin CORS检测使用的外部Origin
+const corsTestOrigin = "https://nox-cors-check.example"
+
+// sessionCookiePattern 常见的会话Cookie名称
+var sessionCookiePattern = regexp.MustCompile(`(?i)sess|token|auth|sid$|^sid|jwt|login|remember`)
+
+// severityRank 风险等级排序
+var severityRank = map[string]int{
+	SeverityHigh:   0,
+	SeverityMedium: 1,
+	SeverityLow:    2,
+	SeverityInfo:   3,
+}
+
+// Finding 安全响应头与Cookie审计发现的问题
+type Finding struct {
+	Severity string // 风险等级
+	Category string // 分类 header/cookie/cors/transport
+	Title    string // 问题描述
+	Detail   string // 相关的响应头或Cookie
+}
+
+// auditResponse 审计页面响应中的安全响应头与Cookie
+func auditResponse(page *pageResponse, https bool) []Finding {
+	findings := make([]Finding, 0)
+	header := page.Header
+	html := strings.Contains(strings.ToLower(header.Get("Content-Type")), "text/html")
+
+	// 传输安全
+	if !https {
+		findings = append(findings, Finding{SeverityMedium, "transport", "Page served over plain HTTP", page.URL})
+	} else {
+		findings = append(findings, auditHSTS(header.Get("Strict-Transport-Security"))...)
+	}
+
+	// 内容安全策略与点击劫持
+	csp := header.Get("Content-Security-Policy")
+	if html {
+		findings = append(findings, auditCSP(csp)...)
+		findings = append(findings, auditFrameOptions(header.Get("X-Frame-Options"), csp)...)
+	}
+
+	if value := header.Get("X-Content-Type-Options"); !strings.EqualFold(strings.TrimSpace(value), "nosniff") {
+		findings = append(findings, Finding{SeverityLow, "header", "Missing X-Content-Type-Options: nosniff", headerDetail("X-Content-Type-Options", value)})
+	}
+
+	referrer := strings.ToLower(strings.TrimSpace(header.Get("Referrer-Policy")))
+	switch {
+	case referrer == "":
+		findings = append(findings, Finding{SeverityLow, "header", "Missing Referrer-Policy", ""})
+	case strings.Contains(referrer, "unsafe-url"), strings.Contains(referrer, "no-referrer-when-downgrade"):
+		findings = append(findings, Finding{SeverityLow, "header", "Referrer-Policy leaks full URLs to other origins", headerDetail("Referrer-Policy", referrer)})
+	}
+
+	findings = append(findings, auditCookies(header, https)...)
+
+	// 跳转过程中设置的Cookie同样会被浏览器保存
+	for i, redirect := range page.Redirects {
+		if i >= len(page.HopHeader) {
+			break
+		}
+		for _, finding := range auditCookies(page.HopHeader[i], strings.HasPrefix(redirect.URL, "https://")) {
+			finding.Detail += " (set by redirect " + redirect.URL + ")"
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// auditHSTS 检查HSTS配置
+func auditHSTS(value string) []Finding {
+	if value == "" {
+		return []Finding{{SeverityMedium, "header", "Missing Strict-Transport-Security", ""}}
+	}
+
+	maxAge := -1
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "max-age") {
+			if n, err := strconv.Atoi(strings.Trim(arg, `" `)); err == nil {
+				maxAge = n
+			}
+		}
+	}
+	switch {
+	case maxAge < 0:
+		return []Finding{{SeverityLow, "header", "Strict-Transport-Security without a valid max-age", headerDetail("Strict-Transport-Security", value)}}
+	case maxAge < minHSTSMaxAge:
+		return []Finding{{SeverityLow, "header", "Strict-Transport-Security max-age shorter than 180 days", headerDetail("Strict-Transport-Security", value)}}
+	}
+	return nil
+}
+
+// auditCSP 检查内容安全策略
+func auditCSP(value string) []Finding {
+	if value == "" {
+		return []Finding{{SeverityMedium, "header", "Missing Content-Security-Policy", ""}}
+	}
+
+	directives := parseCSP(value)
+	scripts, ok := directives["script-src"]
+	if !ok {
+		scripts, ok = directives["default-src"]
+	}
+	if !ok {
+		return []Finding{{SeverityMedium, "header", "Content-Security-Policy does not restrict scripts (no script-src or default-src)", headerDetail("Content-Security-Policy", value)}}
+	}
+
+	findings := make([]Finding, 0)
+	for _, source := range scripts {
+		switch strings.ToLower(source) {
+		case "*", "http:", "https:", "data:":
+			findings = append(findings, Finding{SeverityMedium, "header", fmt.Sprintf("Content-Security-Policy allows scripts from %s", source), headerDetail("Content-Security-Policy", value)})
+		case "'unsafe-inline'":
+			// 存在nonce或hash时浏览器会忽略unsafe-inline
+			if !hasNonceOrHash(scripts) {
+				findings = append(findings, Finding{SeverityLow, "header", "Content-Security-Policy allows 'unsafe-inline' scripts", headerDetail("Content-Security-Policy", value)})
+			}
+		case "'unsafe-eval'":
+			findings = append(findings, Finding{SeverityLow, "header", "Content-Security-Policy allows 'unsafe-eval'", headerDetail("Content-Security-Policy", value)})
+		}
+	}
+	return findings
+}
+
+// auditFrameOptions 检查点击劫持防护，CSP的frame-ancestors优先于X-Frame-Options
+func auditFrameOptions(value, csp string) []Finding {
+	if _, ok := parseCSP(csp)["frame-ancestors"]; ok {
+		return nil
+	}
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "":
+		return []Finding{{SeverityMedium, "header", "Missing X-Frame-Options (clickjacking)", ""}}
+	case "DENY", "SAMEORIGIN":
+		return nil
+	}
+	return []Finding{{SeverityLow, "header", "X-Frame-Options uses an unsupported value", headerDetail("X-Frame-Options", value)}}
+}
+
+// auditCookies 检查Cookie的Secure、HttpOnly与SameSite属性
+func auditCookies(header http.Header, https bool) []Finding {
+	findings := make([]Finding, 0)
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		session := sessionCookiePattern.MatchString(cookie.Name)
+		detail := "cookie " + cookie.Name
+
+		if https && !cookie.Secure {
+			findings = append(findings, Finding{pick(session, SeverityMedium, SeverityLow), "cookie", "Cookie without Secure flag", detail})
+		}
+		if !cookie.HttpOnly {
+			findings = append(findings, Finding{pick(session, SeverityMedium, SeverityLow), "cookie", "Cookie without HttpOnly flag", detail})
+		}
+		// 未设置SameSite时解析结果为0
+		switch cookie.SameSite {
+		case 0, http.SameSiteDefaultMode:
+			findings = append(findings, Finding{SeverityLow, "cookie", "Cookie without SameSite attribute", detail})
+		case http.SameSiteNoneMode:
+			if !cookie.Secure {
+				findings = append(findings, Finding{SeverityMedium, "cookie", "Cookie with SameSite=None but without Secure", detail})
+			}
+		}
+	}
+	return findings
+}
+
+// auditCORS 发送带外部Origin的请求，检查CORS是否反射任意来源
+func auditCORS(client *http.Client, target string) []Finding {
+	findings := make([]Finding, 0)
+	for _, origin := range []string{corsTestOrigin, "null"} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			return findings
+		}
+		req.Header.Set("Origin", origin)
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		allowed := resp.Header.Get("Access-Control-Allow-Origin")
+		credentials := strings.EqualFold(resp.Header.Get("Access-Control-Allow-Credentials"), "true")
+		detail := fmt.Sprintf("Origin: %s -> Access-Control-Allow-Origin: %s, Access-Control-Allow-Credentials: %v", origin, allowed, credentials)
+
+		switch {
+		case allowed == origin && credentials:
+			findings = append(findings, Finding{SeverityHigh, "cors", fmt.Sprintf("CORS reflects %s origin with credentials", originLabel(origin)), detail})
+		case allowed == origin:
+			findings = append(findings, Finding{SeverityMedium, "cors", fmt.Sprintf("CORS reflects %s origin", originLabel(origin)), detail})
+		case allowed == "*" && origin == corsTestOrigin:
+			findings = append(findings, Finding{SeverityInfo, "cors", "CORS allows any origin (*)", detail})
+		}
+	}
+	return findings
+}
+
+// sortFindings 按风险等级排序
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if severityRank[findings[i].Severity] != severityRank[findings[j].Severity] {
+			return severityRank[findings[i].Severity] < severityRank[findings[j].Severity]
+		}
+		return findings[i].Title < findings[j].Title
+	})
+}
+
+// parseCSP 解析内容安全策略，返回指令与来源列表
+func parseCSP(value string) map[string][]string {
+	directives := make(map[string][]string)
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := directives[name]; !ok {
+			directives[name] = fields[1:]
+		}
+	}
+	return directives
+}
+
+// hasNonceOrHash 判断来源列表中是否包含nonce或hash
+func hasNonceOrHash(sources []string) bool {
+	for _, source := range sources {
+		source = strings.ToLower(source)
+		if strings.HasPrefix(source, "'nonce-") || strings.HasPrefix(source, "'sha") {
+			return true
+		}
+	}
+	return false
+}
+
+// headerDetail 格式化响应头
+func headerDetail(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	return name + ": " + value
+}
+
+// originLabel 返回Origin的描述
+func originLabel(origin string) string {
+	if origin == "null" {
+		return "null"
+	}
+	return "arbitrary"
+}
+
+// pick 按条件选择风险等级
+func pick(condition bool, yes, no string) string {
+	if condition {
+		return yes
+	}
+	return no
+}
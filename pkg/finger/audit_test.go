@@ -0,0 +1,221 @@
+package finger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// findingTitles 返回问题描述列表
+func findingTitles(findings []Finding) []string {
+	titles := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		titles = append(titles, finding.Severity+" "+finding.Title)
+	}
+	return titles
+}
+
+func TestAuditHSTS(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"medium Missing Strict-Transport-Security"}},
+		{"max-age=31536000; includeSubDomains; preload", []string{}},
+		{`MAX-AGE="15552000"`, []string{}},
+		{"max-age=3600", []string{"low Strict-Transport-Security max-age shorter than 180 days"}},
+		{"includeSubDomains", []string{"low Strict-Transport-Security without a valid max-age"}},
+		{"max-age=forever", []string{"low Strict-Transport-Security without a valid max-age"}},
+	}
+	for _, tt := range tests {
+		if got := findingTitles(auditHSTS(tt.value)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("auditHSTS(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAuditCSP(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", []string{"medium Missing Content-Security-Policy"}},
+		{"default-src 'self'", []string{}},
+		{"img-src *; frame-ancestors 'none'", []string{"medium Content-Security-Policy does not restrict scripts (no script-src or default-src)"}},
+		{"default-src *", []string{"medium Content-Security-Policy allows scripts from *"}},
+		{"default-src 'self'; script-src 'self' https: data:", []string{"medium Content-Security-Policy allows scripts from https:", "medium Content-Security-Policy allows scripts from data:"}},
+		{"script-src 'self' 'unsafe-inline' 'unsafe-eval'", []string{"low Content-Security-Policy allows 'unsafe-inline' scripts", "low Content-Security-Policy allows 'unsafe-eval'"}},
+		{"script-src 'self' 'unsafe-inline' 'nonce-r4nd0m'", []string{}},
+		{"script-src 'unsafe-inline' 'sha256-abc='", []string{}},
+		{"SCRIPT-SRC 'self'; script-src *", []string{}},
+	}
+	for _, tt := range tests {
+		if got := findingTitles(auditCSP(tt.value)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("auditCSP(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAuditFrameOptions(t *testing.T) {
+	tests := []struct {
+		value string
+		csp   string
+		want  []string
+	}{
+		{"", "", []string{"medium Missing X-Frame-Options (clickjacking)"}},
+		{"DENY", "", []string{}},
+		{" sameorigin ", "", []string{}},
+		{"ALLOW-FROM https://a.test", "", []string{"low X-Frame-Options uses an unsupported value"}},
+		{"", "default-src 'self'; frame-ancestors 'none'", []string{}},
+		{"ALLOW-FROM https://a.test", "frame-ancestors https://a.test", []string{}},
+	}
+	for _, tt := range tests {
+		if got := findingTitles(auditFrameOptions(tt.value, tt.csp)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("auditFrameOptions(%q, %q) = %q, want %q", tt.value, tt.csp, got, tt.want)
+		}
+	}
+}
+
+func TestAuditCookies(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		https  bool
+		want   []string
+	}{
+		{
+			name:   "hardened session cookie",
+			cookie: "SESSIONID=abc; Secure; HttpOnly; SameSite=Lax",
+			https:  true,
+			want:   []string{},
+		},
+		{
+			name:   "bare session cookie over https",
+			cookie: "PHPSESSID=abc",
+			https:  true,
+			want:   []string{"medium Cookie without Secure flag", "medium Cookie without HttpOnly flag", "low Cookie without SameSite attribute"},
+		},
+		{
+			name:   "bare session cookie over http",
+			cookie: "auth_token=abc; SameSite=Strict",
+			want:   []string{"medium Cookie without HttpOnly flag"},
+		},
+		{
+			name:   "non-session cookie",
+			cookie: "lang=en; SameSite=Lax",
+			https:  true,
+			want:   []string{"low Cookie without Secure flag", "low Cookie without HttpOnly flag"},
+		},
+		{
+			name:   "samesite none without secure",
+			cookie: "sid=abc; HttpOnly; SameSite=None",
+			want:   []string{"medium Cookie with SameSite=None but without Secure"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Set-Cookie": []string{tt.cookie}}
+			if got := findingTitles(auditCookies(header, tt.https)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("auditCookies(%q) = %q, want %q", tt.cookie, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuditRedirectCookies 跳转响应中设置的Cookie同样被审计
+func TestAuditRedirectCookies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "session=abc; Path=/")
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "lang=en; Secure; HttpOnly; SameSite=Lax")
+		w.Write([]byte("home"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	page, err := fetchPage(&http.Client{Timeout: 5 * time.Second}, server.URL+"/login")
+	if err != nil {
+		t.Fatalf("fetchPage: %v", err)
+	}
+
+	cookies := make([]string, 0)
+	for _, finding := range auditResponse(page, false) {
+		if finding.Category == "cookie" {
+			cookies = append(cookies, finding.Title+": "+finding.Detail)
+		}
+	}
+	hop := " (set by redirect " + server.URL + "/login)"
+	want := []string{
+		"Cookie without HttpOnly flag: cookie session" + hop,
+		"Cookie without SameSite attribute: cookie session" + hop,
+	}
+	if !reflect.DeepEqual(cookies, want) {
+		t.Errorf("cookie findings = %q, want %q", cookies, want)
+	}
+}
+
+func TestAuditCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, origin string)
+		want    []string
+	}{
+		{
+			name: "reflects with credentials",
+			handler: func(w http.ResponseWriter, origin string) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			},
+			want: []string{"high CORS reflects arbitrary origin with credentials", "high CORS reflects null origin with credentials"},
+		},
+		{
+			name: "reflects without credentials",
+			handler: func(w http.ResponseWriter, origin string) {
+				if origin != "null" {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			},
+			want: []string{"medium CORS reflects arbitrary origin"},
+		},
+		{
+			name: "wildcard",
+			handler: func(w http.ResponseWriter, origin string) {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			},
+			want: []string{"info CORS allows any origin (*)"},
+		},
+		{
+			name: "fixed origin",
+			handler: func(w http.ResponseWriter, origin string) {
+				w.Header().Set("Access-Control-Allow-Origin", "https://app.test")
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origins := make([]string, 0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				origins = append(origins, r.Header.Get("Origin"))
+				tt.handler(w, r.Header.Get("Origin"))
+			}))
+			defer server.Close()
+
+			findings := auditCORS(server.Client(), server.URL+"/api")
+			if got := findingTitles(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("auditCORS() = %q, want %q", got, tt.want)
+			}
+			if strings.Join(origins, ",") != corsTestOrigin+",null" {
+				t.Errorf("sent origins %q, want %s and null", origins, corsTestOrigin)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package finger
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	wappalyzer "github.com/projectdiscovery/wappalyzergo"
+	"github.com/seaung/nox/pkg/utils"
+)
+
+// BatchScanner 批量指纹识别扫描器
+type BatchScanner struct {
+	Targets    []string      // 目标列表，可以是URL、主机名或 主机:端口
+	Timeout    time.Duration // 请求超时时间
+	Concurrent int           // 同时识别的目标数量
+	Probes     int           // 单个目标主动探测的并发数量
+	Rate       int           // 每个主机每秒最大请求数，0表示不限制
+	MaxProbes  int           // 单个目标最多主动请求的路径数量，0表示不限制
+	Audit      bool          // 是否审计安全响应头与Cookie
+	Rules      []*Rule       // 自定义指纹规则
+	Logger     *utils.Logger // 日志记录器，默认输出到标准错误，避免与标准输出中的结果混在一起
+}
+
+// BatchResult 批量识别中单个目标的结果
+type BatchResult struct {
+	Input  string        // 输入的目标
+	Result *FingerResult // 识别结果，失败时为空
+	Err    error         // 所有候选地址均失败时的最后一个错误
+}
+
+// hostLimitedTransport 按主机限速的HTTP传输层
+type hostLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+	limiters map[string]*utils.RateLimiter
+	mu       sync.Mutex
+}
+
+// NewBatchScanner 创建一个新的批量指纹识别扫描器实例
+func NewBatchScanner(targets []string) *BatchScanner {
+	return &BatchScanner{
+		Targets:    targets,
+		Timeout:    time.Second * 10,
+		Concurrent: 20,
+		Probes:     10,
+		MaxProbes:  50,
+		Rules:      DefaultRules(),
+		Logger:     utils.NewWithWriter(os.Stderr),
+	}
+}
+
+// SetTimeout 设置请求超时时间
+func (bs *BatchScanner) SetTimeout(timeout time.Duration) {
+	bs.Timeout = timeout
+}
+
+// SetConcurrent 设置同时识别的目标数量
+func (bs *BatchScanner) SetConcurrent(concurrent int) {
+	bs.Concurrent = concurrent
+}
+
+// SetProbes 设置单个目标主动探测的并发数量
+func (bs *BatchScanner) SetProbes(probes int) {
+	bs.Probes = probes
+}
+
+// SetRate 设置每个主机每秒最大请求数
+func (bs *BatchScanner) SetRate(rate int) {
+	bs.Rate = rate
+}
+
+// SetMaxProbes 设置单个目标最多主动请求的路径数量
+func (bs *BatchScanner) SetMaxProbes(maxProbes int) {
+	bs.MaxProbes = maxProbes
+}
+
+// SetAudit 设置是否审计安全响应头与Cookie
+func (bs *BatchScanner) SetAudit(audit bool) {
+	bs.Audit = audit
+}
+
+// AddRules 添加自定义指纹规则
+func (bs *BatchScanner) AddRules(rules []*Rule) {
+	bs.Rules = append(bs.Rules, rules...)
+}
+
+// LoadTargets 逐行读取目标，取每行第一个字段，忽略空行与#注释
+func LoadTargets(reader io.Reader) ([]string, error) {
+	targets := make([]string, 0)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		targets = append(targets, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %v", err)
+	}
+	return targets, nil
+}
+
+// Scan 使用工作池并发识别所有目标，每个目标完成后立即写入返回的通道
+func (bs *BatchScanner) Scan() (<-chan BatchResult, error) {
+	engine, err := wappalyzer.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wappalyzer instance: %v", err)
+	}
+	transport := newHostLimitedTransport(bs.Rate, bs.Concurrent)
+
+	concurrent := bs.Concurrent
+	if concurrent <= 0 {
+		concurrent = 1
+	}
+	jobs := make(chan string, concurrent)
+	results := make(chan BatchResult, concurrent)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for input := range jobs {
+				results <- bs.scanTarget(input, engine, transport)
+			}
+		}()
+	}
+
+	go func() {
+		for _, target := range bs.Targets {
+			jobs <- target
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, nil
+}
+
+// scanTarget 依次尝试目标的候选地址，返回第一个成功的识别结果
+func (bs *BatchScanner) scanTarget(input string, engine *wappalyzer.Wappalyze, transport http.RoundTripper) BatchResult {
+	var lastErr error
+	for _, target := range candidateURLs(input) {
+		fs := NewFingerScanner(target)
+		fs.Timeout = bs.Timeout
+		fs.Concurrent = bs.Probes
+		fs.MaxProbes = bs.MaxProbes
+		fs.Audit = bs.Audit
+		fs.Rules = bs.Rules
+		fs.Logger = bs.Logger
+		fs.engine = engine
+		fs.transport = transport
+
+		result, err := fs.Scan()
+		if err == nil {
+			return BatchResult{Input: input, Result: result}
+		}
+		lastErr = err
+	}
+	return BatchResult{Input: input, Err: lastErr}
+}
+
+// candidateURLs 为目标生成候选URL
+// 已带协议的目标原样返回，裸主机名与 主机:端口 依据端口决定先尝试https还是http
+func candidateURLs(input string) []string {
+	if strings.Contains(input, "://") {
+		return []string{input}
+	}
+
+	host, port, err := net.SplitHostPort(input)
+	if err != nil {
+		// 没有端口的主机名或IP
+		host, port = strings.Trim(input, "[]"), ""
+	}
+	address := host
+	if strings.Contains(host, ":") {
+		address = "[" + host + "]"
+	}
+	if port != "" {
+		address += ":" + port
+	}
+
+	switch port {
+	case "80", "8000", "8080", "8888":
+		return []string{"http://" + address, "https://" + address}
+	}
+	return []string{"https://" + address, "http://" + address}
+}
+
+// newHostLimitedTransport 创建批量扫描使用的传输层
+// 批量目标中自签名证书很常见，因此不校验证书
+func newHostLimitedTransport(rate, concurrent int) *hostLimitedTransport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	base.MaxIdleConns = concurrent * 2
+	base.MaxIdleConnsPerHost = 4
+
+	transport := &hostLimitedTransport{
+		base:     base,
+		limiters: make(map[string]*utils.RateLimiter),
+	}
+	if rate > 0 {
+		transport.interval = time.Second / time.Duration(rate)
+	}
+	return transport
+}
+
+// RoundTrip 等待目标主机的限速器后发送请求
+func (t *hostLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.interval > 0 {
+		t.limiter(req.URL.Host).Wait()
+	}
+	return t.base.RoundTrip(req)
+}
+
+// limiter 返回主机对应的限速器
+func (t *hostLimitedTransport) limiter(host string) *utils.RateLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rl, ok := t.limiters[host]
+	if !ok {
+		rl = utils.NewRateLimiter(t.interval)
+		t.limiters[host] = rl
+	}
+	return rl
+}
@@ -0,0 +1,58 @@
+package finger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadTargets(t *testing.T) {
+	input := strings.Join([]string{
+		"# targets",
+		"example.com",
+		"",
+		"   ",
+		"https://www.example.com/login extra columns",
+		"\t10.0.0.1:8080\t# office",
+		"#commented.example.com",
+		"example.com",
+		"[2001:db8::1]:8443",
+	}, "\n")
+
+	got, err := LoadTargets(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadTargets: %v", err)
+	}
+	want := []string{"example.com", "https://www.example.com/login", "10.0.0.1:8080", "[2001:db8::1]:8443"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadTargets() = %q, want %q", got, want)
+	}
+
+	got, err = LoadTargets(strings.NewReader("\n# only comments\n"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("LoadTargets(comments) = %q, %v, want empty", got, err)
+	}
+}
+
+func TestCandidateURLs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"example.com", []string{"https://example.com", "http://example.com"}},
+		{"10.0.0.1", []string{"https://10.0.0.1", "http://10.0.0.1"}},
+		{"example.com:8443", []string{"https://example.com:8443", "http://example.com:8443"}},
+		{"example.com:80", []string{"http://example.com:80", "https://example.com:80"}},
+		{"10.0.0.1:8080", []string{"http://10.0.0.1:8080", "https://10.0.0.1:8080"}},
+		{"2001:db8::1", []string{"https://[2001:db8::1]", "http://[2001:db8::1]"}},
+		{"[2001:db8::1]", []string{"https://[2001:db8::1]", "http://[2001:db8::1]"}},
+		{"[2001:db8::1]:8888", []string{"http://[2001:db8::1]:8888", "https://[2001:db8::1]:8888"}},
+		{"http://example.com:8443/app", []string{"http://example.com:8443/app"}},
+		{"https://example.com", []string{"https://example.com"}},
+	}
+	for _, tt := range tests {
+		if got := candidateURLs(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("candidateURLs(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
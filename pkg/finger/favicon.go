@@ -0,0 +1,153 @@
+package finger
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// linkPattern HTML中的link标签
+	linkPattern = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	// attrPattern 标签中的属性
+	attrPattern = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// Favicon 网站图标及其哈希
+type Favicon struct {
+	URL  string // 图标地址
+	MMH3 int32  // Shodan风格的mmh3哈希 (对base64编码后的内容计算)
+	MD5  string // 原始内容的MD5
+}
+
+// hashes 返回用于规则匹配的哈希字符串
+func (f Favicon) hashes() []string {
+	return []string{strconv.Itoa(int(f.MMH3)), f.MD5}
+}
+
+// fetchFavicons 获取/favicon.ico以及页面中<link rel=icon>指向的图标并计算哈希
+// 图标地址相对于跟随跳转后的最终地址解析
+func fetchFavicons(client *http.Client, page *pageResponse) []Favicon {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil
+	}
+	candidates := []string{base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()}
+	for _, href := range iconLinks(page.Body) {
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, base.ResolveReference(ref).String())
+	}
+
+	favicons := make([]Favicon, 0)
+	seen := make(map[string]bool)
+	for _, iconURL := range candidates {
+		if seen[iconURL] {
+			continue
+		}
+		seen[iconURL] = true
+
+		page, err := fetchPage(client, iconURL)
+		if err != nil || page.StatusCode != http.StatusOK || len(page.Body) == 0 {
+			continue
+		}
+		// 跳过返回HTML错误页的情况
+		if strings.Contains(strings.ToLower(page.Header.Get("Content-Type")), "text/html") {
+			continue
+		}
+
+		sum := md5.Sum(page.Body)
+		favicons = append(favicons, Favicon{
+			URL:  iconURL,
+			MMH3: faviconHash(page.Body),
+			MD5:  hex.EncodeToString(sum[:]),
+		})
+	}
+	return favicons
+}
+
+// iconLinks 提取页面中rel包含icon的link标签地址
+func iconLinks(body []byte) []string {
+	links := make([]string, 0)
+	for _, tag := range linkPattern.FindAll(body, -1) {
+		attrs := make(map[string]string)
+		for _, match := range attrPattern.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(match[1]))] = string(match[2]) + string(match[3]) + string(match[4])
+		}
+		if strings.Contains(strings.ToLower(attrs["rel"]), "icon") && attrs["href"] != "" {
+			links = append(links, attrs["href"])
+		}
+	}
+	return links
+}
+
+// faviconHash 计算Shodan风格的favicon哈希
+// 先按Python base64.encodebytes的格式编码 (每76个字符换行，末尾带换行)，再计算mmh3
+func faviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var sb strings.Builder
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		sb.WriteString(encoded[i:end])
+		sb.WriteByte('\n')
+	}
+	return int32(murmur3([]byte(sb.String()), 0))
+}
+
+// murmur3 MurmurHash3 x86 32位实现
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	nblocks := len(data) / 4
+	for i := 0; i < nblocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := data[nblocks*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,46 @@
+package finger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFetchFaviconsAfterRedirect 相对图标地址按跳转后的页面地址解析
+func TestFetchFaviconsAfterRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "/portal/login", http.StatusFound)
+	})
+	mux.HandleFunc("/portal/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="shortcut icon" href="static/logo.ico"></head></html>`))
+	})
+	mux.HandleFunc("/portal/static/logo.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Write([]byte("portal icon"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := server.Client()
+	page, err := fetchPage(client, server.URL+"/")
+	if err != nil {
+		t.Fatalf("fetchPage: %v", err)
+	}
+
+	favicons := fetchFavicons(client, page)
+	if len(favicons) != 1 {
+		t.Fatalf("fetchFavicons() = %+v, want 1 favicon", favicons)
+	}
+	if want := server.URL + "/portal/static/logo.ico"; favicons[0].URL != want {
+		t.Errorf("favicon URL = %s, want %s", favicons[0].URL, want)
+	}
+	if favicons[0].MMH3 != faviconHash([]byte("portal icon")) {
+		t.Errorf("favicon MMH3 = %d, want the hash of the icon body", favicons[0].MMH3)
+	}
+}
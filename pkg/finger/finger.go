@@ -2,8 +2,9 @@ package finger
 
 import (
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	wappalyzer "github.com/projectdiscovery/wappalyzergo"
@@ -12,23 +13,36 @@ import (
 
 // FingerScanner 网站指纹识别扫描器结构体
 type FingerScanner struct {
-	Target   string         // 目标URL
-	Timeout  time.Duration // 请求超时时间
-	Logger   *utils.Logger // 日志记录器
+	Target     string        // 目标URL
+	Timeout    time.Duration // 请求超时时间
+	Concurrent int           // 主动探测的并发数量
+	MaxProbes  int           // 单个目标最多主动请求的路径数量，0表示不限制
+	Audit      bool          // 是否审计安全响应头与Cookie
+	Rules      []*Rule       // 自定义指纹规则
+	Logger     *utils.Logger // 日志记录器
+
+	engine    *wappalyzer.Wappalyze // wappalyzer实例，为空时在扫描时创建
+	transport http.RoundTripper     // HTTP传输层，为空时使用默认传输层
 }
 
 // FingerResult 指纹识别结果结构体
 type FingerResult struct {
-	URL         string   // 目标URL
-	Technologies []string // 识别到的技术列表
+	URL          string       // 目标URL
+	Page         PageInfo     // 页面基本信息
+	Technologies []Technology // 识别到的技术，按名称排序
+	Favicons     []Favicon    // 网站图标及其哈希
+	Findings     []Finding    // 安全响应头与Cookie审计发现的问题，按风险等级排序
 }
 
 // NewFingerScanner 创建一个新的指纹识别扫描器实例
 func NewFingerScanner(target string) *FingerScanner {
 	return &FingerScanner{
-		Target:   target,
-		Timeout:  time.Second * 10,
-		Logger:   utils.New(),
+		Target:     target,
+		Timeout:    time.Second * 10,
+		Concurrent: 10,
+		MaxProbes:  50,
+		Rules:      DefaultRules(),
+		Logger:     utils.New(),
 	}
 }
 
@@ -37,42 +51,109 @@ func (fs *FingerScanner) SetTimeout(timeout time.Duration) {
 	fs.Timeout = timeout
 }
 
+// SetConcurrent 设置主动探测的并发数量
+func (fs *FingerScanner) SetConcurrent(concurrent int) {
+	fs.Concurrent = concurrent
+}
+
+// SetMaxProbes 设置单个目标最多主动请求的路径数量
+func (fs *FingerScanner) SetMaxProbes(maxProbes int) {
+	fs.MaxProbes = maxProbes
+}
+
+// SetAudit 设置是否审计安全响应头与Cookie
+func (fs *FingerScanner) SetAudit(audit bool) {
+	fs.Audit = audit
+}
+
+// AddRules 添加自定义指纹规则
+func (fs *FingerScanner) AddRules(rules []*Rule) {
+	fs.Rules = append(fs.Rules, rules...)
+}
+
 // Scan 执行指纹识别扫描
 func (fs *FingerScanner) Scan() (*FingerResult, error) {
 	// 创建HTTP客户端
 	client := &http.Client{
-		Timeout: fs.Timeout,
+		Timeout:   fs.Timeout,
+		Transport: fs.transport,
 	}
 
-	// 发送HTTP请求
-	resp, err := client.Get(fs.Target)
+	base, err := url.Parse(fs.Target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
+		return nil, fmt.Errorf("invalid target URL: %v", err)
 	}
-	defer resp.Body.Close()
 
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
+	// 发送HTTP请求并读取响应内容
+	page, err := fetchPage(client, fs.Target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
+	info := pageInfo(page)
+	fs.Logger.Info(fmt.Sprintf("%s [%d] [%s] [%d bytes] [%s]", info.FinalURL, info.StatusCode, info.Title, info.ContentLength, info.ResponseTime.Round(time.Millisecond)))
 
-	// 创建wappalyzer实例
-	wappalyzerClient, err := wappalyzer.New()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create wappalyzer instance: %v", err)
+	// 创建wappalyzer实例，批量扫描时共用同一个实例
+	if fs.engine == nil {
+		fs.engine, err = wappalyzer.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create wappalyzer instance: %v", err)
+		}
 	}
 
 	// 识别技术
-	techs := wappalyzerClient.Fingerprint(resp.Header, body)
-	technologies := make([]string, 0)
-	for tech := range techs {
-		technologies = append(technologies, tech)
+	technologies := newTechnologySet()
+	for app, info := range fs.engine.FingerprintWithInfo(page.Header, page.Body) {
+		tech := technologies.addWappalyzer(app, info)
 		fs.Logger.Success(fmt.Sprintf("Detected technology: %s", tech))
 	}
 
+	// 获取网站图标并计算哈希
+	favicons := fetchFavicons(client, page)
+	for _, favicon := range favicons {
+		fs.Logger.Info(fmt.Sprintf("Favicon %s (mmh3: %d, md5: %s)", favicon.URL, favicon.MMH3, favicon.MD5))
+	}
+
+	// 匹配自定义指纹规则
+	ctx := &matchContext{
+		client:   client,
+		base:     base,
+		main:     page,
+		favicons: favicons,
+		limit:    fs.MaxProbes,
+		pages:    make(map[string]*pageResponse),
+	}
+	fs.probe(ctx)
+
+	for _, rule := range fs.Rules {
+		found, matched := rule.match(ctx)
+		if !matched {
+			continue
+		}
+		for i := range found {
+			found[i].Rule = rule.Name
+		}
+		tech := technologies.addRule(rule, found)
+		for _, e := range found {
+			fs.Logger.Success(fmt.Sprintf("Detected technology: %s (path: %s, pattern: %s, snippet: %s)", tech, e.Path, e.Pattern, e.Snippet))
+		}
+	}
+
+	// 审计安全响应头与Cookie
+	findings := make([]Finding, 0)
+	if fs.Audit {
+		findings = append(findings, auditResponse(page, strings.HasPrefix(page.URL, "https://"))...)
+		findings = append(findings, auditCORS(client, page.URL)...)
+		sortFindings(findings)
+		for _, finding := range findings {
+			fs.Logger.Warnning(fmt.Sprintf("[%s] %s %s", finding.Severity, finding.Title, finding.Detail))
+		}
+	}
+
 	return &FingerResult{
-		URL:         fs.Target,
-		Technologies: technologies,
+		URL:          fs.Target,
+		Page:         info,
+		Technologies: technologies.list(),
+		Favicons:     favicons,
+		Findings:     findings,
 	}, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+package finger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// maxRedirects 最多跟随的跳转次数，与net/http默认值一致
+const maxRedirects = 10
+
+// Redirect 跳转链中的一次跳转
+type Redirect struct {
+	URL        string // 发生跳转的地址
+	StatusCode int    // 跳转状态码
+}
+
+// Certificate TLS证书信息
+type Certificate struct {
+	CommonName string    // 证书主体CN
+	DNSNames   []string  // SAN中的域名
+	Issuer     string    // 签发者CN
+	NotAfter   time.Time // 过期时间
+}
+
+// PageInfo 目标页面的基本信息
+type PageInfo struct {
+	Title         string        // HTML标题
+	FinalURL      string        // 跟随跳转后的最终地址
+	Redirects     []Redirect    // 跳转链
+	StatusCode    int           // 最终响应的状态码
+	ContentLength int           // 响应内容长度
+	ContentType   string        // 响应内容类型
+	Server        string        // Server响应头
+	ResponseTime  time.Duration // 响应耗时
+	BodyHash      string        // 响应内容的SHA-256
+	Certificate   *Certificate  // TLS证书，非HTTPS时为空
+}
+
+// pageInfo 从响应中提取页面信息
+func pageInfo(page *pageResponse) PageInfo {
+	sum := sha256.Sum256(page.Body)
+	info := PageInfo{
+		Title:         page.Title,
+		FinalURL:      page.URL,
+		Redirects:     page.Redirects,
+		StatusCode:    page.StatusCode,
+		ContentLength: len(page.Body),
+		ContentType:   page.Header.Get("Content-Type"),
+		Server:        page.Header.Get("Server"),
+		ResponseTime:  page.Duration,
+		BodyHash:      hex.EncodeToString(sum[:]),
+	}
+
+	if page.TLS != nil && len(page.TLS.PeerCertificates) > 0 {
+		cert := page.TLS.PeerCertificates[0]
+		info.Certificate = &Certificate{
+			CommonName: cert.Subject.CommonName,
+			DNSNames:   cert.DNSNames,
+			Issuer:     cert.Issuer.CommonName,
+			NotAfter:   cert.NotAfter,
+		}
+	}
+	return info
+}
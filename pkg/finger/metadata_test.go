@@ -0,0 +1,127 @@
+package finger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pageDelay 测试页面的响应延迟
+const pageDelay = 20 * time.Millisecond
+
+func TestFetchPageRedirects(t *testing.T) {
+	body := "<html><head><title> Login </title></head></html>"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusFound)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(pageDelay)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Server", "nginx")
+		w.Write([]byte(body))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := server.Client()
+	page, err := fetchPage(client, server.URL+"/a")
+	if err != nil {
+		t.Fatalf("fetchPage: %v", err)
+	}
+	if client.CheckRedirect != nil {
+		t.Error("fetchPage modified the caller's CheckRedirect")
+	}
+
+	info := pageInfo(page)
+	wantRedirects := []Redirect{
+		{URL: server.URL + "/a", StatusCode: http.StatusMovedPermanently},
+		{URL: server.URL + "/b", StatusCode: http.StatusFound},
+	}
+	if !reflect.DeepEqual(info.Redirects, wantRedirects) {
+		t.Errorf("Redirects = %+v, want %+v", info.Redirects, wantRedirects)
+	}
+	sum := sha256.Sum256([]byte(body))
+	want := PageInfo{
+		Title:         "Login",
+		FinalURL:      server.URL + "/c",
+		Redirects:     wantRedirects,
+		StatusCode:    http.StatusOK,
+		ContentLength: len(body),
+		ContentType:   "text/html; charset=utf-8",
+		Server:        "nginx",
+		ResponseTime:  info.ResponseTime,
+		BodyHash:      hex.EncodeToString(sum[:]),
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("pageInfo() = %+v, want %+v", info, want)
+	}
+	if info.ResponseTime < pageDelay {
+		t.Errorf("ResponseTime = %s, want at least %s", info.ResponseTime, pageDelay)
+	}
+
+	// 超过最大跳转次数时返回错误
+	if _, err := fetchPage(client, server.URL+"/loop"); err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("fetchPage(/loop) error = %v, want stopped after %d redirects", err, maxRedirects)
+	}
+}
+
+func TestFetchPageCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	page, err := fetchPage(server.Client(), server.URL+"/")
+	if err != nil {
+		t.Fatalf("fetchPage: %v", err)
+	}
+	info := pageInfo(page)
+	if info.FinalURL != server.URL+"/home" || len(info.Redirects) != 1 {
+		t.Errorf("pageInfo() = %+v, want one redirect to /home", info)
+	}
+
+	cert := server.Certificate()
+	want := &Certificate{
+		CommonName: cert.Subject.CommonName,
+		DNSNames:   cert.DNSNames,
+		Issuer:     cert.Issuer.CommonName,
+		NotAfter:   cert.NotAfter,
+	}
+	if !reflect.DeepEqual(info.Certificate, want) {
+		t.Errorf("Certificate = %+v, want %+v", info.Certificate, want)
+	}
+	if len(want.DNSNames) == 0 {
+		t.Error("test certificate has no DNS names")
+	}
+}
+
+// TestPageInfoWithoutTLS 非HTTPS响应没有证书信息
+func TestPageInfoWithoutTLS(t *testing.T) {
+	info := pageInfo(&pageResponse{URL: "http://127.0.0.1/", StatusCode: http.StatusOK, Header: http.Header{}})
+	if info.Certificate != nil {
+		t.Errorf("Certificate = %+v, want nil", info.Certificate)
+	}
+	if info.Redirects != nil || info.ContentLength != 0 {
+		t.Errorf("pageInfo() = %+v, want no redirects and empty body", info)
+	}
+	// 空响应内容的哈希
+	if want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"; info.BodyHash != want {
+		t.Errorf("BodyHash = %s, want %s", info.BodyHash, want)
+	}
+}
@@ -0,0 +1,91 @@
+package finger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// snippetContext 证据片段中命中内容前后保留的字符数
+const snippetContext = 40
+
+// Evidence 规则命中的证据
+type Evidence struct {
+	Rule    string // 命中的规则名称
+	Path    string // 命中的请求路径
+	Pattern string // 命中的关键字、正则或哈希
+	Snippet string // 命中位置附近的内容片段
+	Version string // 从命中内容中提取的版本号
+}
+
+// probePaths 返回规则中需要主动请求的路径，按规则顺序去重
+func probePaths(rules []*Rule) []string {
+	paths := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		for _, m := range rule.Matchers {
+			if m.Path == "" || seen[m.Path] {
+				continue
+			}
+			seen[m.Path] = true
+			paths = append(paths, m.Path)
+		}
+	}
+	return paths
+}
+
+// probe 并发请求规则中的路径并缓存响应，请求数量不超过ctx.limit
+func (fs *FingerScanner) probe(ctx *matchContext) {
+	paths := probePaths(fs.Rules)
+	if ctx.limit > 0 && len(paths) > ctx.limit {
+		fs.Logger.Warnning(fmt.Sprintf("Rules reference %d paths, only probing the first %d", len(paths), ctx.limit))
+		paths = paths[:ctx.limit]
+	}
+	if len(paths) == 0 {
+		return
+	}
+	fs.Logger.Info(fmt.Sprintf("Probing %d paths on %s", len(paths), fs.Target))
+
+	jobs := make(chan string, len(paths))
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+
+	concurrent := fs.Concurrent
+	if concurrent <= 0 {
+		concurrent = 1
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				ctx.fetch(path)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// snippet 截取text中[start, end)附近的内容，并压缩空白字符
+func snippet(text string, start, end int) string {
+	from := start - snippetContext
+	if from < 0 {
+		from = 0
+	}
+	to := end + snippetContext
+	if to > len(text) {
+		to = len(text)
+	}
+	// 避免截断多字节字符
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return strings.Join(strings.Fields(text[from:to]), " ")
+}
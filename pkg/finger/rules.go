@@ -0,0 +1,466 @@
+package finger
+
+import (
+	"crypto/tls"
+	"embed"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules/*.yaml
+var embeddedRules embed.FS
+
+// titlePattern HTML页面标题
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// 匹配条件之间的逻辑关系
+const (
+	ConditionAnd = "and"
+	ConditionOr  = "or"
+)
+
+// 匹配器类型
+const (
+	MatchHeader  = "header"  // 响应头
+	MatchBody    = "body"    // 响应内容
+	MatchTitle   = "title"   // 页面标题
+	MatchStatus  = "status"  // 状态码
+	MatchCookie  = "cookie"  // Cookie名称
+	MatchFavicon = "favicon" // favicon哈希
+)
+
+// Rule 自定义指纹规则，支持YAML/JSON格式
+type Rule struct {
+	Name       string     `yaml:"name" json:"name"`             // 产品名称
+	Categories []string   `yaml:"categories" json:"categories"` // 产品分类
+	Condition  string     `yaml:"condition" json:"condition"`   // 多个匹配器之间的关系 and/or，默认or
+	Matchers   []*Matcher `yaml:"matchers" json:"matchers"`     // 匹配器列表
+	CPE        string     `yaml:"cpe" json:"cpe"`               // CPE 2.3名称，版本部分可为*
+	Website    string     `yaml:"website" json:"website"`       // 产品官网
+	Confidence int        `yaml:"confidence" json:"confidence"` // 置信度 (0-100)，默认100
+}
+
+// Matcher 规则中的单个匹配条件
+type Matcher struct {
+	Type      string   `yaml:"type" json:"type"`           // 匹配器类型 header/body/title/status/cookie/favicon
+	Path      string   `yaml:"path" json:"path"`           // 非空时请求该路径，并对其响应进行匹配
+	Key       string   `yaml:"key" json:"key"`             // header类型时的响应头名称，为空时匹配全部响应头
+	Words     []string `yaml:"words" json:"words"`         // 关键字，大小写不敏感
+	Regex     []string `yaml:"regex" json:"regex"`         // 正则表达式
+	Status    []int    `yaml:"status" json:"status"`       // 状态码
+	Hash      []string `yaml:"hash" json:"hash"`           // favicon哈希 (mmh3或md5)
+	Condition string   `yaml:"condition" json:"condition"` // 多个关键字/正则之间的关系 and/or，默认or
+	Version   string   `yaml:"version" json:"version"`     // 提取版本号的正则，取第一个捕获组
+
+	regexps []*regexp.Regexp // 编译后的正则表达式
+	version *regexp.Regexp   // 编译后的版本号正则
+}
+
+// pageResponse 指纹匹配使用的HTTP响应
+type pageResponse struct {
+	URL        string               // 跟随跳转后的最终地址
+	StatusCode int                  // 状态码
+	Header     http.Header          // 响应头
+	Body       []byte               // 响应内容
+	Title      string               // 页面标题
+	Duration   time.Duration        // 从发出请求到读完响应的耗时
+	Redirects  []Redirect           // 跳转链，不含最终地址
+	HopHeader  []http.Header        // 跳转响应的响应头，与Redirects一一对应
+	TLS        *tls.ConnectionState // TLS连接信息，非HTTPS时为空
+}
+
+// matchContext 一次指纹识别中规则匹配所需的上下文
+type matchContext struct {
+	client   *http.Client             // HTTP客户端
+	base     *url.URL                 // 目标地址
+	main     *pageResponse            // 目标地址的响应
+	favicons []Favicon                // 网站图标
+	limit    int                      // 最多请求的路径数量，0表示不限制
+	pages    map[string]*pageResponse // 已请求路径的响应缓存，请求失败时为nil
+	mu       sync.Mutex               // 保护pages
+}
+
+// DefaultRules 返回内置的指纹规则
+// 内置规则随程序一起编译，无法解析时说明规则文件有误，直接panic而不是忽略
+func DefaultRules() []*Rule {
+	rules := make([]*Rule, 0)
+	entries, err := embeddedRules.ReadDir("rules")
+	if err != nil {
+		panic(fmt.Sprintf("failed to read embedded rules: %v", err))
+	}
+	for _, entry := range entries {
+		data, err := embeddedRules.ReadFile("rules/" + entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("failed to read embedded rules %s: %v", entry.Name(), err))
+		}
+		parsed, err := parseRules(data)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded rules %s: %v", entry.Name(), err))
+		}
+		rules = append(rules, parsed...)
+	}
+	return rules
+}
+
+// LoadRules 读取目录(或单个文件)中所有YAML/JSON格式的指纹规则
+func LoadRules(path string) ([]*Rule, error) {
+	rules := make([]*Rule, 0)
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseRules(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		rules = append(rules, parsed...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %v", err)
+	}
+
+	return rules, nil
+}
+
+// parseRules 解析规则文件，YAML解析器同样兼容JSON
+func parseRules(data []byte) ([]*Rule, error) {
+	rules := make([]*Rule, 0)
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %v", err)
+	}
+	for _, rule := range rules {
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// compile 校验规则并编译其中的正则表达式
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule without name")
+	}
+	if len(r.Matchers) == 0 {
+		return fmt.Errorf("rule %s has no matchers", r.Name)
+	}
+	if err := checkCondition(r.Condition); err != nil {
+		return fmt.Errorf("rule %s: %v", r.Name, err)
+	}
+	if r.Confidence == 0 {
+		r.Confidence = 100
+	}
+	if r.Confidence < 0 || r.Confidence > 100 {
+		return fmt.Errorf("rule %s: confidence must be between 0 and 100", r.Name)
+	}
+
+	for _, m := range r.Matchers {
+		switch m.Type {
+		case MatchHeader, MatchBody, MatchTitle, MatchStatus, MatchCookie, MatchFavicon:
+		default:
+			return fmt.Errorf("rule %s: unknown matcher type %q", r.Name, m.Type)
+		}
+		if err := checkCondition(m.Condition); err != nil {
+			return fmt.Errorf("rule %s: %v", r.Name, err)
+		}
+
+		m.regexps = make([]*regexp.Regexp, 0, len(m.Regex))
+		for _, pattern := range m.Regex {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid regex %q: %v", r.Name, pattern, err)
+			}
+			m.regexps = append(m.regexps, re)
+		}
+		if m.Version != "" {
+			re, err := regexp.Compile(m.Version)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid version regex %q: %v", r.Name, m.Version, err)
+			}
+			m.version = re
+		}
+	}
+
+	return nil
+}
+
+// checkCondition 校验逻辑关系
+func checkCondition(condition string) error {
+	switch strings.ToLower(condition) {
+	case "", ConditionAnd, ConditionOr:
+		return nil
+	}
+	return fmt.Errorf("unknown condition %q", condition)
+}
+
+// match 判断规则是否命中，并返回命中的证据
+func (r *Rule) match(ctx *matchContext) ([]Evidence, bool) {
+	and := strings.EqualFold(r.Condition, ConditionAnd)
+	evidence := make([]Evidence, 0)
+	for _, m := range r.Matchers {
+		found, matched := m.match(ctx)
+		if and && !matched {
+			return nil, false
+		}
+		if !and && matched {
+			return found, true
+		}
+		evidence = append(evidence, found...)
+	}
+	if !and {
+		return nil, false
+	}
+	return evidence, true
+}
+
+// match 判断匹配器是否命中，并返回命中的证据
+func (m *Matcher) match(ctx *matchContext) ([]Evidence, bool) {
+	if m.Type == MatchFavicon {
+		return m.matchFavicon(ctx.favicons)
+	}
+
+	resp := ctx.main
+	path := ctx.base.RequestURI()
+	if m.Path != "" {
+		resp = ctx.fetch(m.Path)
+		path = m.Path
+	}
+	if resp == nil {
+		return nil, false
+	}
+
+	if m.Type == MatchStatus {
+		if !containsInt(m.Status, resp.StatusCode) {
+			return nil, false
+		}
+		return []Evidence{{
+			Path:    path,
+			Pattern: fmt.Sprintf("status %d", resp.StatusCode),
+			Snippet: fmt.Sprintf("HTTP %d", resp.StatusCode),
+		}}, true
+	}
+
+	var text string
+	switch m.Type {
+	case MatchHeader:
+		lines := make([]string, 0, len(resp.Header))
+		if m.Key != "" {
+			for _, value := range resp.Header.Values(m.Key) {
+				lines = append(lines, http.CanonicalHeaderKey(m.Key)+": "+value)
+			}
+		} else {
+			for key, values := range resp.Header {
+				for _, value := range values {
+					lines = append(lines, key+": "+value)
+				}
+			}
+			sort.Strings(lines)
+		}
+		text = strings.Join(lines, "\n")
+	case MatchCookie:
+		names := make([]string, 0)
+		for _, cookie := range (&http.Response{Header: resp.Header}).Cookies() {
+			names = append(names, cookie.Name)
+		}
+		text = strings.Join(names, "\n")
+	case MatchTitle:
+		text = resp.Title
+	case MatchBody:
+		// 同时指定状态码时需要状态码也一致
+		if len(m.Status) > 0 && !containsInt(m.Status, resp.StatusCode) {
+			return nil, false
+		}
+		text = string(resp.Body)
+	}
+
+	evidence, matched := m.matchText(text)
+	if !matched {
+		return nil, false
+	}
+
+	// 从同一段内容中提取版本号
+	version := ""
+	if m.version != nil {
+		if match := m.version.FindStringSubmatch(text); len(match) > 1 {
+			version = match[1]
+		}
+	}
+	for i := range evidence {
+		evidence[i].Path = path
+		evidence[i].Version = version
+	}
+	return evidence, true
+}
+
+// matchText 按关键字与正则匹配文本，返回命中的模式及上下文片段
+func (m *Matcher) matchText(text string) ([]Evidence, bool) {
+	and := strings.EqualFold(m.Condition, ConditionAnd)
+	lower := strings.ToLower(text)
+	total := len(m.Words) + len(m.regexps)
+	if total == 0 {
+		return nil, false
+	}
+
+	// 大小写转换可能改变长度，此时在转换后的文本上截取片段
+	source := text
+	if len(lower) != len(text) {
+		source = lower
+	}
+
+	evidence := make([]Evidence, 0, total)
+	for _, word := range m.Words {
+		idx := strings.Index(lower, strings.ToLower(word))
+		matched := idx >= 0
+		if and && !matched {
+			return nil, false
+		}
+		if matched {
+			evidence = append(evidence, Evidence{Pattern: word, Snippet: snippet(source, idx, idx+len(word))})
+			if !and {
+				return evidence, true
+			}
+		}
+	}
+	for _, re := range m.regexps {
+		loc := re.FindStringIndex(text)
+		matched := loc != nil
+		if and && !matched {
+			return nil, false
+		}
+		if matched {
+			evidence = append(evidence, Evidence{Pattern: re.String(), Snippet: snippet(text, loc[0], loc[1])})
+			if !and {
+				return evidence, true
+			}
+		}
+	}
+	if !and {
+		return nil, false
+	}
+	return evidence, true
+}
+
+// matchFavicon 判断favicon哈希是否命中
+func (m *Matcher) matchFavicon(favicons []Favicon) ([]Evidence, bool) {
+	for _, want := range m.Hash {
+		for _, favicon := range favicons {
+			for _, hash := range favicon.hashes() {
+				if strings.EqualFold(want, hash) {
+					return []Evidence{{Path: favicon.URL, Pattern: want, Snippet: "favicon hash " + hash}}, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// fetch 请求目标站点下的指定路径，同一路径只请求一次
+func (ctx *matchContext) fetch(path string) *pageResponse {
+	ctx.mu.Lock()
+	if resp, ok := ctx.pages[path]; ok {
+		ctx.mu.Unlock()
+		return resp
+	}
+	// 超过单个目标的请求上限后不再发起新的请求
+	if ctx.limit > 0 && len(ctx.pages) >= ctx.limit {
+		ctx.mu.Unlock()
+		return nil
+	}
+	ctx.mu.Unlock()
+
+	var resp *pageResponse
+	if ref, err := url.Parse(path); err == nil {
+		resp, _ = fetchPage(ctx.client, ctx.base.ResolveReference(ref).String())
+	}
+
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.pages[path] = resp
+	return resp
+}
+
+// fetchPage 请求页面并读取响应
+// 跳转链通过复制的客户端记录，不影响调用方的客户端配置
+func fetchPage(client *http.Client, pageURL string) (*pageResponse, error) {
+	redirects := make([]Redirect, 0)
+	hopHeader := make([]http.Header, 0)
+	recorder := *client
+	recorder.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		redirects = append(redirects, Redirect{URL: req.Response.Request.URL.String(), StatusCode: req.Response.StatusCode})
+		hopHeader = append(hopHeader, req.Response.Header)
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := recorder.Get(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	return &pageResponse{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Title:      extractTitle(body),
+		Duration:   time.Since(start),
+		Redirects:  redirects,
+		HopHeader:  hopHeader,
+		TLS:        resp.TLS,
+	}, nil
+}
+
+// extractTitle 提取HTML页面标题
+func extractTitle(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(string(match[1])))
+}
+
+// containsInt 判断切片中是否包含指定整数
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
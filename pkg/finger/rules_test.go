@@ -0,0 +1,80 @@
+package finger
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestEmbeddedRules 确认每个内置规则文件都能被解析
+func TestEmbeddedRules(t *testing.T) {
+	entries, err := embeddedRules.ReadDir("rules")
+	if err != nil {
+		t.Fatalf("failed to read embedded rules: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("no embedded rule files")
+	}
+
+	total := 0
+	for _, entry := range entries {
+		data, err := embeddedRules.ReadFile("rules/" + entry.Name())
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", entry.Name(), err)
+		}
+		rules, err := parseRules(data)
+		if err != nil {
+			t.Errorf("%s: %v", entry.Name(), err)
+			continue
+		}
+		if len(rules) == 0 {
+			t.Errorf("%s: no rules", entry.Name())
+		}
+		total += len(rules)
+	}
+
+	if got := len(DefaultRules()); got != total {
+		t.Errorf("DefaultRules() returned %d rules, want %d", got, total)
+	}
+}
+
+// TestDeviceRulesGenericWords 通用关键字需要与厂商关键字同时出现才能命中
+func TestDeviceRulesGenericWords(t *testing.T) {
+	// 同名规则可能出现在多个文件中，这里只取device.yaml中的规则
+	data, err := embeddedRules.ReadFile("rules/device.yaml")
+	if err != nil {
+		t.Fatalf("failed to read device.yaml: %v", err)
+	}
+	parsed, err := parseRules(data)
+	if err != nil {
+		t.Fatalf("failed to parse device.yaml: %v", err)
+	}
+	rules := make(map[string]*Rule)
+	for _, rule := range parsed {
+		rules[rule.Name] = rule
+	}
+
+	tests := []struct {
+		rule string
+		body string
+		want bool
+	}{
+		{"Hikvision Web", `<script src="webComponents/webComponents.js"></script>`, false},
+		{"Hikvision Web", `<script src="webComponents/ui.js"></script><script>fetch("/ISAPI/Security/userCheck")</script>`, true},
+		{"Dahua Web", `<html><head><title>WEB SERVICE</title></head></html>`, false},
+		{"Dahua Web", `<html><head><title>WEB SERVICE</title></head><body>Dahua Technology</body></html>`, true},
+		{"H3C Device", `<h1>Web user login</h1>`, false},
+		{"H3C Device", `<h1>Web user login</h1><p>Copyright H3C</p>`, true},
+	}
+	for _, tt := range tests {
+		rule := rules[tt.rule]
+		if rule == nil {
+			t.Fatalf("rule %s not found", tt.rule)
+		}
+		ctx := &matchContext{main: &pageResponse{StatusCode: 200, Header: http.Header{}, Body: []byte(tt.body), Title: extractTitle([]byte(tt.body))}}
+		ctx.base, _ = url.Parse("http://127.0.0.1/")
+		if _, matched := rule.match(ctx); matched != tt.want {
+			t.Errorf("%s on %q matched = %v, want %v", tt.rule, tt.body, matched, tt.want)
+		}
+	}
+}
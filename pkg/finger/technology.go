@@ -0,0 +1,129 @@
+package finger
+
+import (
+	"sort"
+	"strings"
+
+	wappalyzer "github.com/projectdiscovery/wappalyzergo"
+)
+
+// wappalyzerConfidence wappalyzergo未公开匹配置信度，命中时统一视为100
+const wappalyzerConfidence = 100
+
+// Technology 识别到的技术
+type Technology struct {
+	Name       string     // 名称
+	Version    string     // 版本号，未识别时为空
+	Categories []string   // 分类
+	Confidence int        // 置信度 (0-100)
+	CPE        string     // CPE 2.3名称，识别到版本时已填入版本号
+	Website    string     // 官网
+	Evidence   []Evidence // 规则命中的证据
+}
+
+// technologySet 按名称合并多个来源识别到的技术
+type technologySet struct {
+	items map[string]*Technology
+}
+
+// newTechnologySet 创建一个空的技术集合
+func newTechnologySet() *technologySet {
+	return &technologySet{items: make(map[string]*Technology)}
+}
+
+// get 返回指定名称的技术，不存在时创建
+func (ts *technologySet) get(name string) *Technology {
+	key := strings.ToLower(name)
+	tech, ok := ts.items[key]
+	if !ok {
+		tech = &Technology{Name: name, Categories: make([]string, 0), Evidence: make([]Evidence, 0)}
+		ts.items[key] = tech
+	}
+	return tech
+}
+
+// addWappalyzer 合并wappalyzergo的识别结果，键的格式为 名称 或 名称:版本
+func (ts *technologySet) addWappalyzer(app string, info wappalyzer.AppInfo) *Technology {
+	name, version, _ := strings.Cut(app, ":")
+	tech := ts.get(name)
+	tech.merge(version, info.Categories, wappalyzerConfidence, info.CPE, info.Website)
+	return tech
+}
+
+// addRule 合并自定义规则的识别结果，版本号取第一个提取到版本的证据
+func (ts *technologySet) addRule(rule *Rule, evidence []Evidence) *Technology {
+	version := ""
+	for _, e := range evidence {
+		if e.Version != "" {
+			version = e.Version
+			break
+		}
+	}
+	tech := ts.get(rule.Name)
+	tech.merge(version, rule.Categories, rule.Confidence, rule.CPE, rule.Website)
+	tech.Evidence = append(tech.Evidence, evidence...)
+	return tech
+}
+
+// list 返回按名称排序的技术列表
+func (ts *technologySet) list() []Technology {
+	technologies := make([]Technology, 0, len(ts.items))
+	for _, tech := range ts.items {
+		tech.CPE = cpeWithVersion(tech.CPE, tech.Version)
+		sort.Strings(tech.Categories)
+		technologies = append(technologies, *tech)
+	}
+	sort.Slice(technologies, func(i, j int) bool {
+		return strings.ToLower(technologies[i].Name) < strings.ToLower(technologies[j].Name)
+	})
+	return technologies
+}
+
+// merge 补充缺失的信息，分类取并集，置信度取最大值
+func (t *Technology) merge(version string, categories []string, confidence int, cpe, website string) {
+	if t.Version == "" {
+		t.Version = version
+	}
+	if t.CPE == "" {
+		t.CPE = cpe
+	}
+	if t.Website == "" {
+		t.Website = website
+	}
+	if confidence > t.Confidence {
+		t.Confidence = confidence
+	}
+	for _, category := range categories {
+		if !containsString(t.Categories, category) {
+			t.Categories = append(t.Categories, category)
+		}
+	}
+}
+
+// String 返回 名称 或 名称/版本 形式的描述
+func (t Technology) String() string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return t.Name + "/" + t.Version
+}
+
+// cpeWithVersion 在版本部分为*的CPE 2.3名称中填入版本号
+func cpeWithVersion(cpe, version string) string {
+	parts := strings.Split(cpe, ":")
+	if version == "" || len(parts) < 6 || parts[0] != "cpe" || parts[1] != "2.3" || parts[5] != "*" {
+		return cpe
+	}
+	parts[5] = version
+	return strings.Join(parts, ":")
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
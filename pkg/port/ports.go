@@ -3,6 +3,7 @@ package port
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -69,7 +70,7 @@ func (ps *PortScanner) SetPortRange(start, end int) error {
 
 // tcpConnect 使用TCP连接方式扫描单个端口
 func (ps *PortScanner) tcpConnect(port int) ScanResult {
-	target := fmt.Sprintf("%s:%d", ps.Target, port)
+	target := net.JoinHostPort(ps.Target, strconv.Itoa(port))
 	conn, err := net.DialTimeout("tcp", target, ps.Timeout)
 	result := ScanResult{Port: port}
 	
@@ -86,7 +87,7 @@ func (ps *PortScanner) tcpConnect(port int) ScanResult {
 
 // udpScan 使用UDP方式扫描单个端口
 func (ps *PortScanner) udpScan(port int) ScanResult {
-	target := fmt.Sprintf("%s:%d", ps.Target, port)
+	target := net.JoinHostPort(ps.Target, strconv.Itoa(port))
 	conn, err := net.DialTimeout("udp", target, ps.Timeout)
 	result := ScanResult{Port: port}
 	
@@ -0,0 +1,162 @@
+package subdomain
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubQuery DNS测试服务器收到的一次查询
+type stubQuery struct {
+	Name string
+	Type dnsmessage.Type
+	TCP  bool
+	At   time.Time
+}
+
+// stubHandler 根据查询返回应答报文，返回空时不应答
+// TCP查询可以返回多个报文，UDP查询只发送第一个报文
+// 报文的ID、Response与Questions由测试服务器填写
+type stubHandler func(q dnsmessage.Question, tcp bool) []dnsmessage.Message
+
+// dnsStub 在同一端口上监听UDP与TCP的DNS测试服务器
+type dnsStub struct {
+	Addr string
+
+	udp     net.PacketConn
+	tcp     net.Listener
+	handler stubHandler
+
+	mu      sync.Mutex
+	queries []stubQuery
+}
+
+// newDNSStub 启动DNS测试服务器，测试结束时自动关闭
+func newDNSStub(t *testing.T, handler stubHandler) *dnsStub {
+	t.Helper()
+	stub := &dnsStub{handler: handler}
+
+	// 系统分配的TCP端口对应的UDP端口可能已被占用，多试几次
+	for i := 0; i < 10 && stub.udp == nil; i++ {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen on tcp: %v", err)
+		}
+		udp, err := net.ListenPacket("udp", tcp.Addr().String())
+		if err != nil {
+			tcp.Close()
+			continue
+		}
+		stub.tcp, stub.udp, stub.Addr = tcp, udp, tcp.Addr().String()
+	}
+	if stub.udp == nil {
+		t.Fatal("failed to listen on udp and tcp with the same port")
+	}
+	t.Cleanup(func() {
+		stub.udp.Close()
+		stub.tcp.Close()
+	})
+
+	go stub.serveUDP()
+	go stub.serveTCP()
+	return stub
+}
+
+// Queries 返回已收到的查询
+func (s *dnsStub) Queries() []stubQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubQuery(nil), s.queries...)
+}
+
+// answer 解析查询并调用handler生成打包后的应答
+func (s *dnsStub) answer(raw []byte, tcp bool) [][]byte {
+	query := new(dnsmessage.Message)
+	if err := query.Unpack(raw); err != nil || len(query.Questions) == 0 {
+		return nil
+	}
+	question := query.Questions[0]
+	s.mu.Lock()
+	s.queries = append(s.queries, stubQuery{Name: question.Name.String(), Type: question.Type, TCP: tcp, At: time.Now()})
+	s.mu.Unlock()
+
+	packed := make([][]byte, 0)
+	for _, msg := range s.handler(question, tcp) {
+		msg.ID = query.ID
+		msg.Response = true
+		msg.Questions = query.Questions
+		data, err := msg.Pack()
+		if err != nil {
+			panic("failed to pack stub answer: " + err.Error())
+		}
+		packed = append(packed, data)
+	}
+	return packed
+}
+
+func (s *dnsStub) serveUDP() {
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if answers := s.answer(buf[:n], false); len(answers) > 0 {
+			s.udp.WriteTo(answers[0], addr)
+		}
+	}
+}
+
+func (s *dnsStub) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			raw, err := readTCPMessage(conn)
+			if err != nil {
+				return
+			}
+			for _, answer := range s.answer(raw, true) {
+				if err := writeTCPMessage(conn, answer); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// mustName 将字符串转换为dnsmessage.Name
+func mustName(name string) dnsmessage.Name {
+	return dnsmessage.MustNewName(fqdn(name))
+}
+
+// aRecord 构造A记录
+func aRecord(name, ip string) dnsmessage.Resource {
+	var a [4]byte
+	copy(a[:], net.ParseIP(ip).To4())
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(name), Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   &dnsmessage.AResource{A: a},
+	}
+}
+
+// stubResolver 创建只使用给定测试服务器、从第一个服务器开始查询的DNS客户端
+func stubResolver(timeout time.Duration, retries int, stubs ...*dnsStub) *Resolver {
+	servers := make([]string, 0, len(stubs))
+	for _, stub := range stubs {
+		servers = append(servers, stub.Addr)
+	}
+	r := NewResolver(servers)
+	r.Timeout = timeout
+	r.Retries = retries
+	// Exchange先递增next再取模，使第一次查询从Servers[0]开始
+	r.next = uint32(len(servers) - 1)
+	return r
+}
@@ -2,35 +2,85 @@ package subdomain
 
 import (
 	"fmt"
-	"net"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/seaung/nox/pkg/utils"
 )
 
+// 子域名的发现来源
+const (
+	SourceBruteforce = "bruteforce" // 字典爆破
+	SourceAXFR       = "axfr"       // 区域传送
+	SourceNSEC       = "nsec"       // NSEC遍历
+	SourcePermute    = "permute"    // 置换变体
+	SourceRecursive  = "recursive"  // 多级递归爆破
+	SourcePTR        = "ptr"        // 邻近网段反向解析
+)
+
 // SubdomainScanner 子域名扫描器结构体
 type SubdomainScanner struct {
-	Domain     string         // 目标域名
-	Wordlist   []string      // 字典列表
-	Timeout    time.Duration // DNS查询超时时间
-	Concurrent int          // 并发数量
-	Logger     *utils.Logger // 日志记录器
+	Domain        string        // 目标域名
+	Wordlist      []string      // 字典列表
+	Timeout       time.Duration // DNS查询超时时间
+	Concurrent    int           // 并发数量
+	WildcardTests int           // 泛解析检测时每一层级探测的随机标签数量，为0时不检测
+	Records       bool          // 是否收集MX/NS/TXT/SRV/CAA等完整DNS记录
+	Takeover      bool          // 是否检测子域名接管
+	ZoneWalk      bool          // 爆破前是否尝试区域传送(AXFR)与NSEC遍历
+	Recursive     bool          // 是否以已发现的子域名为基础递归爆破
+	MaxDepth      int           // 递归爆破时相对目标域名的最大标签层数
+	Reverse       bool          // 是否对已发现IP的邻近网段做反向解析
+	ReversePrefix int           // 反向解析邻近网段的前缀长度
+	Permute       bool          // 爆破后是否基于已发现的名称生成变体继续解析
+	PermuteBudget int           // 置换阶段最多解析的候选数量
+	Resolver      *Resolver     // DNS客户端
+	Logger        *utils.Logger // 日志记录器
+
+	TakeoverSignatures []TakeoverSignature // 子域名接管特征，为nil时使用内置特征
+	ZoneServers        []string            // 区域传送与NSEC遍历使用的权威服务器(host:port)，为空时查询NS记录
+	PermuteWords       []string            // 置换时插入与替换的常见词，为nil时使用内置词表
+	RecursiveWordlist  []string            // 递归爆破使用的字典，为nil时使用Wordlist
+	Sources            []Source            // 被动来源，名称经主动解析验证后计入结果
+
+	wildcards  map[string]*wildcardInfo // 各层级的泛解析记录
+	wildcardMu sync.Mutex               // 保护wildcards
 }
 
 // SubdomainResult 子域名扫描结果结构体
 type SubdomainResult struct {
 	Subdomain string   // 子域名
 	IPList    []string // IP地址列表
+	Source    string   // 发现来源
+	CNAMEs    []string // CNAME链，无别名时为空
+
+	// 以下字段仅在开启Records时填充
+	MX    []MXRecord  // 邮件交换记录
+	NS    []string    // 域名服务器记录
+	TXT   []string    // 文本记录
+	SPF   string      // SPF策略 (v=spf1)
+	DMARC string      // DMARC策略 (_dmarc上的v=DMARC1)
+	SRV   []SRVRecord // 常见服务的SRV记录，只在存在MX或NS记录的名称上探测
+	CAA   []CAARecord // 证书颁发机构授权记录
+
+	Takeovers []TakeoverFinding // 子域名接管风险，仅在开启Takeover时填充
 }
 
 // NewSubdomainScanner 创建一个新的子域名扫描器实例
 func NewSubdomainScanner(domain string) *SubdomainScanner {
 	return &SubdomainScanner{
-		Domain:     domain,
-		Timeout:    time.Second * 2,
-		Concurrent: 100,
-		Logger:     utils.New(),
+		Domain:        domain,
+		Timeout:       time.Second * 2,
+		Concurrent:    100,
+		WildcardTests: 5,
+		MaxDepth:      3,
+		ReversePrefix: 24,
+		PermuteBudget: 10000,
+		Resolver:      NewResolver(SystemResolvers()),
+		Logger:        utils.New(),
+		wildcards:     make(map[string]*wildcardInfo),
 	}
 }
 
@@ -42,6 +92,7 @@ func (ss *SubdomainScanner) SetWordlist(wordlist []string) {
 // SetTimeout 设置DNS查询超时时间
 func (ss *SubdomainScanner) SetTimeout(timeout time.Duration) {
 	ss.Timeout = timeout
+	ss.Resolver.Timeout = timeout
 }
 
 // SetConcurrent 设置并发数量
@@ -49,24 +100,226 @@ func (ss *SubdomainScanner) SetConcurrent(concurrent int) {
 	ss.Concurrent = concurrent
 }
 
+// SetWildcardTests 设置泛解析检测的随机标签数量
+func (ss *SubdomainScanner) SetWildcardTests(tests int) {
+	ss.WildcardTests = tests
+}
+
+// SetRecords 设置是否收集完整DNS记录
+func (ss *SubdomainScanner) SetRecords(records bool) {
+	ss.Records = records
+}
+
+// SetTakeover 设置是否检测子域名接管
+func (ss *SubdomainScanner) SetTakeover(takeover bool) {
+	ss.Takeover = takeover
+}
+
+// SetTakeoverSignatures 设置子域名接管特征
+func (ss *SubdomainScanner) SetTakeoverSignatures(signatures []TakeoverSignature) {
+	ss.TakeoverSignatures = signatures
+}
+
+// SetZoneWalk 设置是否尝试区域传送与NSEC遍历
+func (ss *SubdomainScanner) SetZoneWalk(zoneWalk bool) {
+	ss.ZoneWalk = zoneWalk
+}
+
+// SetZoneServers 设置区域传送与NSEC遍历使用的权威服务器
+func (ss *SubdomainScanner) SetZoneServers(servers []string) {
+	ss.ZoneServers = servers
+}
+
+// AddSource 添加一个被动来源
+func (ss *SubdomainScanner) AddSource(source Source) {
+	ss.Sources = append(ss.Sources, source)
+}
+
+// SetRecursive 设置是否递归爆破及最大标签层数
+func (ss *SubdomainScanner) SetRecursive(recursive bool, maxDepth int) {
+	ss.Recursive = recursive
+	ss.MaxDepth = maxDepth
+}
+
+// SetRecursiveWordlist 设置递归爆破使用的字典
+func (ss *SubdomainScanner) SetRecursiveWordlist(wordlist []string) {
+	ss.RecursiveWordlist = wordlist
+}
+
+// SetReverse 设置是否对邻近网段做反向解析及网段前缀长度，开启时前缀长度必须在16到32之间
+func (ss *SubdomainScanner) SetReverse(reverse bool, prefix int) error {
+	if reverse && (prefix < minReversePrefix || prefix > maxReversePrefix) {
+		return fmt.Errorf("invalid reverse prefix length %d: must be between %d and %d", prefix, minReversePrefix, maxReversePrefix)
+	}
+	ss.Reverse = reverse
+	ss.ReversePrefix = prefix
+	return nil
+}
+
+// SetPermute 设置是否进行置换变体扫描及最多解析的候选数量
+func (ss *SubdomainScanner) SetPermute(permute bool, budget int) {
+	ss.Permute = permute
+	ss.PermuteBudget = budget
+}
+
+// SetPermuteWords 设置置换时插入与替换的常见词
+func (ss *SubdomainScanner) SetPermuteWords(words []string) {
+	ss.PermuteWords = words
+}
+
+// SetResolvers 设置DNS服务器列表
+func (ss *SubdomainScanner) SetResolvers(servers []string) {
+	ss.Resolver.Servers = servers
+}
+
+// SetRetries 设置每个DNS服务器的重试次数
+func (ss *SubdomainScanner) SetRetries(retries int) {
+	ss.Resolver.Retries = retries
+}
+
+// SetRate 设置每个DNS服务器每秒最大查询次数
+func (ss *SubdomainScanner) SetRate(rate int) {
+	ss.Resolver.Rate = rate
+}
+
 // dnsLookup 执行DNS查询
 func (ss *SubdomainScanner) dnsLookup(subdomain string) *SubdomainResult {
-	ips, err := net.LookupHost(subdomain)
+	ips, cnames, err := ss.Resolver.LookupHost(subdomain)
 	if err != nil {
+		// 检测接管时保留CNAME目标无法解析的悬挂记录
+		if ss.Takeover && len(cnames) > 0 {
+			return &SubdomainResult{
+				Subdomain: subdomain,
+				CNAMEs:    cnames,
+			}
+		}
 		return nil
 	}
 
 	return &SubdomainResult{
 		Subdomain: subdomain,
 		IPList:    ips,
+		CNAMEs:    cnames,
 	}
 }
 
 // Scan 执行子域名扫描
 func (ss *SubdomainScanner) Scan() []SubdomainResult {
+	// 扫描前先检测目标域名是否存在泛解析
+	if info := ss.detectWildcard(ss.Domain); info != nil {
+		ss.Logger.Warnning(fmt.Sprintf("Wildcard DNS detected on *.%s (IPs: %v, CNAMEs: %v)", ss.Domain, info.ipList(), info.cnameList()))
+	}
+
+	state := newScanState()
+
+	// 爆破前先尝试区域传送与NSEC遍历
+	if ss.ZoneWalk {
+		sources, zoneIPs := ss.enumerateZone()
+		for _, source := range []string{SourceAXFR, SourceNSEC} {
+			names := make([]string, 0)
+			for name, from := range sources {
+				if from == source {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			found := ss.resolveNames(state.untried(names), source)
+			// 区域内的记录可能无法公开解析，使用区域数据中的地址
+			for i := range found {
+				if len(found[i].IPList) == 0 {
+					found[i].IPList = zoneIPs[found[i].Subdomain]
+				}
+			}
+			state.add(found)
+		}
+	}
+
+	// 被动来源中的名称经主动解析验证后合并
+	for _, source := range ss.Sources {
+		names, err := source.Subdomains(ss.Domain)
+		if err != nil {
+			ss.Logger.LoggerError(fmt.Sprintf("Source %s failed: %v", source.Name(), err))
+			continue
+		}
+		ss.Logger.Info(fmt.Sprintf("Source %s returned %d names", source.Name(), len(names)))
+		state.add(ss.resolveNames(state.untried(names), source.Name()))
+	}
+
+	candidates := make([]string, 0, len(ss.Wordlist))
+	for _, word := range ss.Wordlist {
+		candidates = append(candidates, fmt.Sprintf("%s.%s", word, ss.Domain))
+	}
+	state.add(ss.resolveNames(state.untried(candidates), SourceBruteforce))
+
+	// 以已发现的子域名为基础逐层爆破
+	if ss.Recursive {
+		ss.recurse(state)
+	}
+
+	// 基于已发现的名称生成变体继续解析
+	if ss.Permute {
+		ss.permute(state)
+	}
+
+	// 反向解析已发现IP的邻近网段
+	if ss.Reverse {
+		ss.pivot(state)
+	}
+
+	return state.results
+}
+
+// scanState 单次扫描过程中的状态，记录已尝试与已发现的名称
+type scanState struct {
+	results []SubdomainResult // 已发现的子域名
+	found   map[string]bool   // 已发现的名称
+	tried   map[string]bool   // 已尝试解析的名称
+}
+
+// newScanState 创建一个空的扫描状态
+func newScanState() *scanState {
+	return &scanState{
+		results: make([]SubdomainResult, 0),
+		found:   make(map[string]bool),
+		tried:   make(map[string]bool),
+	}
+}
+
+// untried 过滤掉已尝试过的名称，并将剩余名称标记为已尝试
+func (st *scanState) untried(names []string) []string {
+	fresh := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !st.tried[name] {
+			st.tried[name] = true
+			fresh = append(fresh, name)
+		}
+	}
+	return fresh
+}
+
+// add 合并新发现的结果，返回此前未发现过的部分
+func (st *scanState) add(found []SubdomainResult) []SubdomainResult {
+	fresh := make([]SubdomainResult, 0, len(found))
+	for _, result := range found {
+		if !st.found[result.Subdomain] {
+			st.found[result.Subdomain] = true
+			st.results = append(st.results, result)
+			fresh = append(fresh, result)
+		}
+	}
+	return fresh
+}
+
+// resolveNames 使用工作池并发解析一组完整域名
+// 区域传送与NSEC遍历得到的名称确定存在，不做泛解析过滤，解析失败时也会保留
+func (ss *SubdomainScanner) resolveNames(names []string, source string) []SubdomainResult {
+	authoritative := source == SourceAXFR || source == SourceNSEC
+
 	results := make([]SubdomainResult, 0)
-	jobs := make(chan string, len(ss.Wordlist))
-	resultsChan := make(chan *SubdomainResult, len(ss.Wordlist))
+	jobs := make(chan string, len(names))
+	resultsChan := make(chan *SubdomainResult, len(names))
 	wg := sync.WaitGroup{}
 
 	// 启动工作协程
@@ -74,20 +327,32 @@ func (ss *SubdomainScanner) Scan() []SubdomainResult {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for subdomain := range jobs {
-				fullDomain := fmt.Sprintf("%s.%s", subdomain, ss.Domain)
-				result := ss.dnsLookup(fullDomain)
-				if result != nil {
-					resultsChan <- result
+			for name := range jobs {
+				result := ss.dnsLookup(name)
+				if result == nil {
+					if !authoritative {
+						continue
+					}
+					result = &SubdomainResult{Subdomain: name}
+				} else if !authoritative && ss.isWildcard(result) {
+					continue
+				}
+				result.Source = source
+				if ss.Records {
+					ss.collectRecords(result)
+				}
+				if ss.Takeover {
+					ss.checkTakeover(result)
 				}
+				resultsChan <- result
 			}
 		}()
 	}
 
 	// 发送任务
 	go func() {
-		for _, word := range ss.Wordlist {
-			jobs <- word
+		for _, name := range names {
+			jobs <- name
 		}
 		close(jobs)
 	}()
@@ -100,9 +365,12 @@ func (ss *SubdomainScanner) Scan() []SubdomainResult {
 
 	// 收集结果
 	for result := range resultsChan {
-		ss.Logger.Success(fmt.Sprintf("Found subdomain: %s (IPs: %v)", result.Subdomain, result.IPList))
+		ss.Logger.Success(fmt.Sprintf("Found subdomain: %s (IPs: %v, Source: %s)", result.Subdomain, result.IPList, result.Source))
+		for _, finding := range result.Takeovers {
+			ss.Logger.Warnning(fmt.Sprintf("Possible takeover [%s] %s -> %s (%s)", finding.Severity, finding.Subdomain, finding.Target, finding.Evidence))
+		}
 		results = append(results, *result)
 	}
 
 	return results
-}
\ No newline at end of file
+}
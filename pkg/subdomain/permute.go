@@ -0,0 +1,170 @@
+package subdomain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultPermuteWords 置换时插入与替换的内置常见词
+var defaultPermuteWords = []string{
+	"dev", "development", "test", "testing", "qa", "uat", "stage", "staging",
+	"pre", "prod", "production", "beta", "demo", "sandbox", "api", "v1", "v2",
+	"v3", "admin", "internal", "intranet", "corp", "old", "new", "backup",
+	"app", "web", "www", "m", "mobile", "cdn", "static", "portal", "vpn", "mail",
+}
+
+// numberPattern 标签中的数字部分
+var numberPattern = regexp.MustCompile(`[0-9]+`)
+
+// labelPattern 合法的DNS标签
+var labelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// permute 基于已发现的名称迭代生成并解析变体，直到没有新名称或达到候选数量上限
+func (ss *SubdomainScanner) permute(state *scanState) {
+	budget := ss.PermuteBudget
+	round := state.results
+
+	for i := 1; len(round) > 0 && budget > 0; i++ {
+		candidates := make([]string, 0)
+		for _, result := range round {
+			candidates = append(candidates, ss.permutations(result.Subdomain)...)
+		}
+		candidates = state.untried(candidates)
+		if len(candidates) == 0 {
+			return
+		}
+		if len(candidates) > budget {
+			candidates = candidates[:budget]
+		}
+		budget -= len(candidates)
+
+		ss.Logger.Info(fmt.Sprintf("Permutation round %d: resolving %d candidates", i, len(candidates)))
+		round = state.add(ss.resolveNames(candidates, SourcePermute))
+	}
+}
+
+// permutations 生成单个名称的变体：前置标签、逐标签变换以及相邻标签合并
+func (ss *SubdomainScanner) permutations(name string) []string {
+	suffix := "." + strings.ToLower(ss.Domain)
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, suffix) {
+		return nil
+	}
+	labels := strings.Split(strings.TrimSuffix(name, suffix), ".")
+
+	words := ss.PermuteWords
+	if words == nil {
+		words = defaultPermuteWords
+	}
+
+	seen := make(map[string]bool)
+	candidates := make([]string, 0)
+	add := func(parts []string) {
+		candidate := strings.Join(parts, ".")
+		if candidate != name && validName(candidate) && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate+suffix)
+		}
+	}
+
+	// 前置标签，例如 dev.example.com -> api.dev.example.com
+	for _, word := range words {
+		add(append([]string{word}, labels...))
+	}
+
+	// 逐标签变换
+	for i, label := range labels {
+		for _, variant := range labelVariants(label, words) {
+			parts := append([]string{}, labels...)
+			parts[i] = variant
+			add(parts)
+		}
+	}
+
+	// 合并相邻标签，例如 api.dev -> api-dev / apidev
+	for i := 0; i+1 < len(labels); i++ {
+		for _, sep := range []string{"-", ""} {
+			parts := append([]string{}, labels[:i]...)
+			parts = append(parts, labels[i]+sep+labels[i+1])
+			parts = append(parts, labels[i+2:]...)
+			add(parts)
+		}
+	}
+
+	return candidates
+}
+
+// labelVariants 生成单个标签的变体：插入、替换常见词，增减数字，连字符与点号互换
+func labelVariants(label string, words []string) []string {
+	variants := make([]string, 0)
+
+	// 插入常见词
+	for _, word := range words {
+		variants = append(variants,
+			label+"-"+word,
+			word+"-"+label,
+			label+word,
+			word+label,
+		)
+	}
+
+	// 替换连字符分隔的常见词，例如 dev-api -> staging-api
+	known := make(map[string]bool, len(words))
+	for _, word := range words {
+		known[word] = true
+	}
+	parts := strings.Split(label, "-")
+	for i, part := range parts {
+		if !known[part] {
+			continue
+		}
+		for _, word := range words {
+			if word == part {
+				continue
+			}
+			replaced := append([]string{}, parts...)
+			replaced[i] = word
+			variants = append(variants, strings.Join(replaced, "-"))
+		}
+	}
+
+	// 增减数字，例如 api1 -> api0/api2/api3，去掉数字 api1 -> api
+	if loc := numberPattern.FindStringIndex(label); loc != nil {
+		prefix, digits, rest := label[:loc[0]], label[loc[0]:loc[1]], label[loc[1]:]
+		if n, err := strconv.Atoi(digits); err == nil {
+			for delta := -1; delta <= 2; delta++ {
+				if delta != 0 && n+delta >= 0 {
+					variants = append(variants, fmt.Sprintf("%s%0*d%s", prefix, len(digits), n+delta, rest))
+				}
+			}
+		}
+		variants = append(variants, strings.Trim(numberPattern.ReplaceAllString(label, ""), "-"))
+	} else {
+		// 追加数字，例如 api -> api1/api2/api-1
+		for n := 1; n <= 3; n++ {
+			variants = append(variants, fmt.Sprintf("%s%d", label, n), fmt.Sprintf("%s-%d", label, n))
+		}
+	}
+
+	// 连字符与点号互换，例如 dev-api -> dev.api / devapi
+	if strings.Contains(label, "-") {
+		variants = append(variants,
+			strings.ReplaceAll(label, "-", "."),
+			strings.ReplaceAll(label, "-", ""),
+		)
+	}
+
+	return variants
+}
+
+// validName 判断相对名称的每个标签是否合法
+func validName(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if !labelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return name != ""
+}
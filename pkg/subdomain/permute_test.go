@@ -0,0 +1,151 @@
+package subdomain
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestLabelVariants(t *testing.T) {
+	tests := []struct {
+		label string
+		words []string
+		want  []string
+	}{
+		{
+			label: "api1",
+			words: []string{"dev"},
+			want:  []string{"api1-dev", "dev-api1", "api1dev", "devapi1", "api0", "api2", "api3", "api"},
+		},
+		{
+			label: "dev-api",
+			words: []string{"dev", "qa"},
+			want: []string{
+				"dev-api-dev", "dev-dev-api", "dev-apidev", "devdev-api",
+				"dev-api-qa", "qa-dev-api", "dev-apiqa", "qadev-api",
+				"qa-api",
+				"dev-api1", "dev-api-1", "dev-api2", "dev-api-2", "dev-api3", "dev-api-3",
+				"dev.api", "devapi",
+			},
+		},
+		{
+			label: "web007",
+			words: []string{},
+			want:  []string{"web006", "web008", "web009", "web"},
+		},
+		{
+			label: "node0",
+			words: nil,
+			want:  []string{"node1", "node2", "node"},
+		},
+		{
+			label: "v2-api",
+			words: nil,
+			want:  []string{"v1-api", "v3-api", "v4-api", "v-api", "v2.api", "v2api"},
+		},
+	}
+	for _, tt := range tests {
+		if got := labelVariants(tt.label, tt.words); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("labelVariants(%q, %q) = %q, want %q", tt.label, tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestPermutations(t *testing.T) {
+	ss := NewSubdomainScanner("Example.com")
+	ss.SetPermuteWords([]string{"dev", "qa"})
+
+	got := ss.permutations("API1.dev.example.com")
+	seen := make(map[string]bool)
+	for _, name := range got {
+		if seen[name] {
+			t.Errorf("duplicate candidate %s", name)
+		}
+		seen[name] = true
+		if name == "api1.dev.example.com" {
+			t.Error("candidates contain the input name")
+		}
+		if !strings.HasSuffix(name, ".example.com") || !validName(strings.TrimSuffix(name, ".example.com")) {
+			t.Errorf("invalid candidate %s", name)
+		}
+	}
+	for _, want := range []string{
+		"qa.api1.dev.example.com",  // 前置标签
+		"api1-qa.dev.example.com",  // 插入常见词
+		"api2.dev.example.com",     // 增减数字
+		"api.dev.example.com",      // 去掉数字
+		"api1.qa.example.com",      // 替换常见词
+		"api1.dev-1.example.com",   // 追加数字
+		"api1-dev.example.com",     // 合并相邻标签
+		"api1dev.example.com",      // 合并相邻标签
+		"dev.api1.dev.example.com", // 前置与已有标签相同的词
+	} {
+		if !seen[want] {
+			t.Errorf("missing candidate %s", want)
+		}
+	}
+	// 前置2个，api1变换12个，dev变换去重后13个，合并2个
+	if len(got) != 29 {
+		t.Errorf("got %d candidates, want 29", len(got))
+	}
+
+	if got := ss.permutations("api.other.com"); got != nil {
+		t.Errorf("permutations outside the domain = %v, want nil", got)
+	}
+}
+
+func TestPermuteBudget(t *testing.T) {
+	// 只有api、dev.api与api-dev存在
+	hosts := map[string]bool{"api.example.test": true, "dev.api.example.test": true, "api-dev.example.test": true}
+	newStub := func() *dnsStub {
+		return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+			if !hosts[strings.TrimSuffix(q.Name.String(), ".")] {
+				return answerRCode(dnsmessage.RCodeNameError)
+			}
+			if q.Type != dnsmessage.TypeA {
+				return answerRCode(dnsmessage.RCodeSuccess)
+			}
+			return answerA(q, "192.0.2.1")
+		})
+	}
+
+	tests := []struct {
+		budget int
+		want   []string
+	}{
+		{0, []string{"api.example.test"}},
+		{1, []string{"api.example.test", "dev.api.example.test"}},
+		{10000, []string{"api-dev.example.test", "api.example.test", "dev.api.example.test"}},
+	}
+	for _, tt := range tests {
+		stub := newStub()
+		ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+		ss.SetConcurrent(8)
+		ss.SetWildcardTests(0)
+		ss.SetWordlist([]string{"api"})
+		ss.SetPermuteWords([]string{"dev"})
+		ss.SetPermute(true, tt.budget)
+
+		got := make([]string, 0)
+		for _, result := range ss.Scan() {
+			got = append(got, result.Subdomain)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("budget %d: Scan() = %v, want %v", tt.budget, got, tt.want)
+		}
+
+		// 除字典中的api外，解析的候选数量不超过预算
+		names := make(map[string]bool)
+		for _, q := range stub.Queries() {
+			names[q.Name] = true
+		}
+		if permuted := len(names) - 1; permuted > tt.budget {
+			t.Errorf("budget %d: resolved %d candidates", tt.budget, permuted)
+		}
+	}
+}
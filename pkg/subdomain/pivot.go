@@ -0,0 +1,301 @@
+package subdomain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// 反向解析邻近网段允许的前缀长度范围，过小的前缀会一次扫描过大的网段
+const (
+	minReversePrefix = 16
+	maxReversePrefix = 32
+)
+
+// ASNEntry ASN数据库中的一个网段
+type ASNEntry struct {
+	Start  net.IP // 起始地址
+	End    net.IP // 结束地址
+	Prefix string // 网段描述 (CIDR或起止地址)
+	ASN    string // 自治系统编号
+	Name   string // 自治系统名称
+}
+
+// ASNDatabase 离线ASN/网段数据库
+type ASNDatabase struct {
+	entries []ASNEntry // 按起始地址排序的网段
+	maxEnd  []net.IP   // maxEnd[i]为entries[0..i]中最大的结束地址，用于提前结束查找
+}
+
+// NetblockGroup 按ASN网段分组的IP
+type NetblockGroup struct {
+	ASN        string   // 自治系统编号，未收录时为空
+	Name       string   // 自治系统名称
+	Prefix     string   // 所属网段
+	IPs        []string // 网段内发现的IP
+	Subdomains []string // 解析到这些IP的子域名
+}
+
+// LoadASNDatabase 读取离线ASN数据库
+// 支持iptoasn格式 (起始地址 结束地址 ASN 国家 名称，制表符分隔) 与
+// CIDR格式 (网段 ASN 名称，空白分隔)，#开头的行为注释
+func LoadASNDatabase(path string) (*ASNDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ASN database: %v", err)
+	}
+	defer file.Close()
+
+	entries := make([]ASNEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if entry, ok := parseASNLine(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ASN database: %v", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no ASN entries found in %s", path)
+	}
+	return newASNDatabase(entries), nil
+}
+
+// newASNDatabase 对网段排序并建立查找索引
+// 起始地址相同时范围较大的网段排在前面，使Lookup优先返回更小的网段
+func newASNDatabase(entries []ASNEntry) *ASNDatabase {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if c := bytes.Compare(entries[i].Start, entries[j].Start); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(entries[i].End, entries[j].End) > 0
+	})
+
+	maxEnd := make([]net.IP, len(entries))
+	for i, entry := range entries {
+		maxEnd[i] = entry.End
+		if i > 0 && bytes.Compare(maxEnd[i-1], entry.End) > 0 {
+			maxEnd[i] = maxEnd[i-1]
+		}
+	}
+	return &ASNDatabase{entries: entries, maxEnd: maxEnd}
+}
+
+// parseASNLine 解析ASN数据库中的一行
+func parseASNLine(line string) (ASNEntry, bool) {
+	if strings.Contains(line, "\t") {
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 3 && !strings.Contains(fields[0], "/") {
+			start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+			if start == nil || end == nil {
+				return ASNEntry{}, false
+			}
+			entry := ASNEntry{
+				Start:  start.To16(),
+				End:    end.To16(),
+				Prefix: fields[0] + "-" + fields[1],
+				ASN:    fields[2],
+			}
+			if len(fields) >= 5 {
+				entry.Name = fields[4]
+			}
+			return entry, true
+		}
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ASNEntry{}, false
+	}
+	_, network, err := net.ParseCIDR(fields[0])
+	if err != nil {
+		return ASNEntry{}, false
+	}
+	return ASNEntry{
+		Start:  network.IP.To16(),
+		End:    lastAddress(network).To16(),
+		Prefix: network.String(),
+		ASN:    strings.TrimPrefix(strings.ToUpper(fields[1]), "AS"),
+		Name:   strings.Join(fields[2:], " "),
+	}, true
+}
+
+// Lookup 查找IP所属的网段，多个网段嵌套时返回起始地址最接近的一个
+func (db *ASNDatabase) Lookup(ip net.IP) *ASNEntry {
+	ip = ip.To16()
+	if ip == nil {
+		return nil
+	}
+
+	// 起始地址不大于ip的网段都可能包含ip，从最接近的一个向前查找
+	// 之前所有网段的最大结束地址小于ip时不可能再有网段包含ip，未命中时无需遍历到开头
+	idx := sort.Search(len(db.entries), func(i int) bool {
+		return bytes.Compare(db.entries[i].Start, ip) > 0
+	})
+
+	for i := idx - 1; i >= 0 && bytes.Compare(db.maxEnd[i], ip) >= 0; i-- {
+		if bytes.Compare(ip, db.entries[i].End) <= 0 {
+			return &db.entries[i]
+		}
+	}
+	return nil
+}
+
+// GroupNetblocks 按ASN网段对结果中的IP分组，未收录的IP归入同一个空ASN分组
+func GroupNetblocks(results []SubdomainResult, db *ASNDatabase) []NetblockGroup {
+	groups := make(map[string]*NetblockGroup)
+	seenIP := make(map[string]map[string]bool)
+	seenName := make(map[string]map[string]bool)
+
+	for _, result := range results {
+		for _, ip := range result.IPList {
+			key := ""
+			group := NetblockGroup{}
+			if entry := db.Lookup(net.ParseIP(ip)); entry != nil {
+				key = entry.Prefix
+				group = NetblockGroup{ASN: entry.ASN, Name: entry.Name, Prefix: entry.Prefix}
+			}
+			if _, ok := groups[key]; !ok {
+				groups[key] = &group
+				seenIP[key] = make(map[string]bool)
+				seenName[key] = make(map[string]bool)
+			}
+
+			if !seenIP[key][ip] {
+				seenIP[key][ip] = true
+				groups[key].IPs = append(groups[key].IPs, ip)
+			}
+			if !seenName[key][result.Subdomain] {
+				seenName[key][result.Subdomain] = true
+				groups[key].Subdomains = append(groups[key].Subdomains, result.Subdomain)
+			}
+		}
+	}
+
+	netblocks := make([]NetblockGroup, 0, len(groups))
+	for _, group := range groups {
+		netblocks = append(netblocks, *group)
+	}
+	sort.Slice(netblocks, func(i, j int) bool {
+		if len(netblocks[i].IPs) != len(netblocks[j].IPs) {
+			return len(netblocks[i].IPs) > len(netblocks[j].IPs)
+		}
+		return netblocks[i].Prefix < netblocks[j].Prefix
+	})
+	return netblocks
+}
+
+// pivot 对已发现IP所在的邻近网段做反向解析，将属于目标域名的新主机名解析后合并
+func (ss *SubdomainScanner) pivot(state *scanState) {
+	prefix := ss.ReversePrefix
+	if prefix < minReversePrefix || prefix > maxReversePrefix {
+		ss.Logger.Warnning(fmt.Sprintf("Skip reverse DNS sweep: invalid prefix length %d", prefix))
+		return
+	}
+
+	networks := make(map[string]*net.IPNet)
+	for _, result := range state.results {
+		for _, ip := range result.IPList {
+			parsed := net.ParseIP(ip).To4()
+			if parsed == nil {
+				continue
+			}
+			network := &net.IPNet{IP: parsed.Mask(net.CIDRMask(prefix, 32)), Mask: net.CIDRMask(prefix, 32)}
+			networks[network.String()] = network
+		}
+	}
+	if len(networks) == 0 {
+		return
+	}
+
+	addresses := make([]net.IP, 0)
+	for _, network := range networks {
+		start := binary.BigEndian.Uint32(network.IP)
+		end := binary.BigEndian.Uint32(lastAddress(network))
+		for n := start; n <= end && n >= start; n++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, n)
+			addresses = append(addresses, ip)
+		}
+	}
+	ss.Logger.Info(fmt.Sprintf("Reverse DNS sweep: %d networks, %d addresses", len(networks), len(addresses)))
+
+	names := state.untried(ss.reverseLookup(addresses))
+	if len(names) > 0 {
+		state.add(ss.resolveNames(names, SourcePTR))
+	}
+}
+
+// reverseLookup 使用工作池并发查询PTR记录，只返回目标域名下的主机名
+func (ss *SubdomainScanner) reverseLookup(addresses []net.IP) []string {
+	jobs := make(chan net.IP, len(addresses))
+	namesChan := make(chan string, len(addresses))
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < ss.Concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				answers, err := ss.Resolver.Lookup(reverseName(ip), dnsmessage.TypePTR)
+				if err != nil {
+					continue
+				}
+				for _, answer := range answers {
+					name := strings.ToLower(trimDot(answer.Body.(*dnsmessage.PTRResource).PTR.String()))
+					if ss.inScope(name) {
+						ss.Logger.Success(fmt.Sprintf("PTR %s -> %s", ip, name))
+						namesChan <- name
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, ip := range addresses {
+			jobs <- ip
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(namesChan)
+	}()
+
+	names := make([]string, 0)
+	for name := range namesChan {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reverseName 返回IPv4地址对应的in-addr.arpa名称
+func reverseName(ip net.IP) string {
+	ip4 := ip.To4()
+	return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0])
+}
+
+// lastAddress 返回网段中的最后一个地址
+func lastAddress(network *net.IPNet) net.IP {
+	last := make(net.IP, len(network.IP))
+	for i := range network.IP {
+		last[i] = network.IP[i] | ^network.Mask[i]
+	}
+	return last
+}
@@ -0,0 +1,164 @@
+package subdomain
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseASNLine(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		want ASNEntry
+	}{
+		{
+			line: "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET",
+			ok:   true,
+			want: ASNEntry{Start: net.ParseIP("1.0.0.0"), End: net.ParseIP("1.0.0.255"), Prefix: "1.0.0.0-1.0.0.255", ASN: "13335", Name: "CLOUDFLARENET"},
+		},
+		{
+			line: "2001:db8::\t2001:db8::ffff\t64500",
+			ok:   true,
+			want: ASNEntry{Start: net.ParseIP("2001:db8::"), End: net.ParseIP("2001:db8::ffff"), Prefix: "2001:db8::-2001:db8::ffff", ASN: "64500"},
+		},
+		{
+			line: "192.0.2.77/24 as64496 Example Net",
+			ok:   true,
+			want: ASNEntry{Start: net.ParseIP("192.0.2.0"), End: net.ParseIP("192.0.2.255"), Prefix: "192.0.2.0/24", ASN: "64496", Name: "Example Net"},
+		},
+		{
+			line: "10.0.0.0/8\tAS64512\tPrivate",
+			ok:   true,
+			want: ASNEntry{Start: net.ParseIP("10.0.0.0"), End: net.ParseIP("10.255.255.255"), Prefix: "10.0.0.0/8", ASN: "64512", Name: "Private"},
+		},
+		{line: "1.0.0.0\tnot-an-ip\t13335", ok: false},
+		{line: "192.0.2.0/33 64496", ok: false},
+		{line: "192.0.2.0/24", ok: false},
+		{line: "garbage", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseASNLine(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseASNLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseASNLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// testASNDatabase 读取包含嵌套、相邻与IPv6网段的测试数据库
+func testASNDatabase(t *testing.T) *ASNDatabase {
+	t.Helper()
+	path := writeFile(t, "asn.txt", `# test database
+10.0.0.0/8 AS64512 Corp
+10.1.0.0/16 AS64513 Corp Lab
+10.1.2.0/24 AS64514 Corp Lab DMZ
+10.0.0.0/16 AS64515 Corp Core
+192.0.2.0	192.0.2.127	64496	ZZ	Doc Low
+192.0.2.128	192.0.2.255	64497	ZZ	Doc High
+2001:db8::/32 AS64500 Doc v6
+2001:db8:1::/48 AS64501 Doc v6 Sub
+`)
+	db, err := LoadASNDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadASNDatabase: %v", err)
+	}
+	return db
+}
+
+func TestASNLookup(t *testing.T) {
+	db := testASNDatabase(t)
+
+	tests := []struct {
+		ip   string
+		want string // 期望的网段，为空表示未收录
+	}{
+		{"10.1.2.3", "10.1.2.0/24"},
+		{"10.1.3.1", "10.1.0.0/16"},
+		{"10.0.0.1", "10.0.0.0/16"},
+		{"10.2.0.1", "10.0.0.0/8"},
+		{"10.255.255.255", "10.0.0.0/8"},
+		{"192.0.2.127", "192.0.2.0-192.0.2.127"},
+		{"192.0.2.128", "192.0.2.128-192.0.2.255"},
+		{"9.255.255.255", ""},
+		{"11.0.0.0", ""},
+		{"192.0.3.1", ""},
+		{"2001:db8:1::53", "2001:db8:1::/48"},
+		{"2001:db8:2::1", "2001:db8::/32"},
+		{"2001:db9::1", ""},
+		{"::1", ""},
+	}
+	for _, tt := range tests {
+		entry := db.Lookup(net.ParseIP(tt.ip))
+		got := ""
+		if entry != nil {
+			got = entry.Prefix
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+
+	if entry := db.Lookup(nil); entry != nil {
+		t.Errorf("Lookup(nil) = %+v, want nil", entry)
+	}
+}
+
+// TestASNLookupMissStopsEarly 未命中时依靠最大结束地址提前结束，不会遍历之前的网段
+func TestASNLookupMissStopsEarly(t *testing.T) {
+	entries := make([]ASNEntry, 0)
+	for i := 0; i < 256; i++ {
+		_, network, _ := net.ParseCIDR(net.IPv4(10, byte(i), 0, 0).String() + "/24")
+		entries = append(entries, ASNEntry{Start: network.IP.To16(), End: lastAddress(network).To16(), Prefix: network.String()})
+	}
+	db := newASNDatabase(entries)
+
+	ip := net.ParseIP("10.255.1.1").To16()
+	if last := db.maxEnd[len(db.maxEnd)-2]; bytes.Compare(last, ip) >= 0 {
+		t.Fatalf("maxEnd before the last entry = %s, want below %s", last, ip)
+	}
+	if entry := db.Lookup(ip); entry != nil {
+		t.Errorf("Lookup(%s) = %+v, want nil", ip, entry)
+	}
+	if entry := db.Lookup(net.ParseIP("10.128.0.9")); entry == nil || entry.Prefix != "10.128.0.0/24" {
+		t.Errorf("Lookup(10.128.0.9) = %+v, want 10.128.0.0/24", entry)
+	}
+}
+
+func TestGroupNetblocks(t *testing.T) {
+	db := testASNDatabase(t)
+	results := []SubdomainResult{
+		{Subdomain: "a.example.com", IPList: []string{"10.1.2.3", "10.1.2.4"}},
+		{Subdomain: "b.example.com", IPList: []string{"10.1.2.3", "203.0.113.9"}},
+		{Subdomain: "c.example.com", IPList: []string{"2001:db8:1::53"}},
+		{Subdomain: "d.example.com", IPList: []string{"198.51.100.1"}},
+	}
+
+	want := []NetblockGroup{
+		{ASN: "", Prefix: "", IPs: []string{"203.0.113.9", "198.51.100.1"}, Subdomains: []string{"b.example.com", "d.example.com"}},
+		{ASN: "64514", Name: "Corp Lab DMZ", Prefix: "10.1.2.0/24", IPs: []string{"10.1.2.3", "10.1.2.4"}, Subdomains: []string{"a.example.com", "b.example.com"}},
+		{ASN: "64501", Name: "Doc v6 Sub", Prefix: "2001:db8:1::/48", IPs: []string{"2001:db8:1::53"}, Subdomains: []string{"c.example.com"}},
+	}
+	if got := GroupNetblocks(results, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupNetblocks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetReverse(t *testing.T) {
+	ss := NewSubdomainScanner("example.com")
+	if err := ss.SetReverse(true, 8); err == nil {
+		t.Error("SetReverse(true, 8) succeeded, want error")
+	}
+	if err := ss.SetReverse(true, 24); err != nil || !ss.Reverse || ss.ReversePrefix != 24 {
+		t.Errorf("SetReverse(true, 24) = %v, reverse %v prefix %d", err, ss.Reverse, ss.ReversePrefix)
+	}
+	// 未开启反向解析时不校验前缀长度
+	if err := ss.SetReverse(false, 0); err != nil || ss.Reverse {
+		t.Errorf("SetReverse(false, 0) = %v, reverse %v", err, ss.Reverse)
+	}
+}
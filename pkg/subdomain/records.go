@@ -0,0 +1,195 @@
+package subdomain
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// typeCAA CAA记录类型，dnsmessage未内置
+const typeCAA dnsmessage.Type = 257
+
+// srvServices 收集SRV记录时探测的常见服务前缀
+var srvServices = []string{
+	"_sip._tcp",
+	"_sip._udp",
+	"_sips._tcp",
+	"_xmpp-client._tcp",
+	"_xmpp-server._tcp",
+	"_ldap._tcp",
+	"_kerberos._tcp",
+	"_kerberos._udp",
+	"_gc._tcp",
+	"_autodiscover._tcp",
+	"_submission._tcp",
+	"_imap._tcp",
+	"_imaps._tcp",
+	"_pop3._tcp",
+	"_pop3s._tcp",
+	"_caldav._tcp",
+	"_carddav._tcp",
+}
+
+// MXRecord 邮件交换记录
+type MXRecord struct {
+	Host       string // 邮件服务器
+	Preference uint16 // 优先级
+}
+
+// SRVRecord 服务定位记录
+type SRVRecord struct {
+	Service  string // 服务前缀，例如_sip._tcp
+	Target   string // 目标主机
+	Port     uint16 // 端口
+	Priority uint16 // 优先级
+	Weight   uint16 // 权重
+}
+
+// CAARecord 证书颁发机构授权记录
+type CAARecord struct {
+	Flag  uint8  // 标志位
+	Tag   string // 标签，例如issue/issuewild/iodef
+	Value string // 值
+}
+
+// Lookup 查询指定类型的记录，只返回该类型的应答
+func (r *Resolver) Lookup(name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	msg, err := r.Exchange(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("%s: %s", name, msg.RCode)
+	}
+
+	answers := make([]dnsmessage.Resource, 0)
+	for _, answer := range msg.Answers {
+		if answer.Header.Type == qtype {
+			answers = append(answers, answer)
+		}
+	}
+	if len(answers) == 0 {
+		return nil, errNoAnswer
+	}
+	return answers, nil
+}
+
+// collectRecords 收集子域名的MX/NS/TXT/SRV/CAA记录，并从TXT中提取SPF与DMARC策略
+// 各类型的查询并发发送；SRV记录一般只配置在邮件域或区域顶点，因此只在存在MX或NS记录的名称上探测
+func (ss *SubdomainScanner) collectRecords(result *SubdomainResult) {
+	name := result.Subdomain
+
+	var mx, ns, caa []dnsmessage.Resource
+	var txt, dmarc []string
+	wg := sync.WaitGroup{}
+	run := func(query func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			query()
+		}()
+	}
+	run(func() { mx, _ = ss.Resolver.Lookup(name, dnsmessage.TypeMX) })
+	run(func() { ns, _ = ss.Resolver.Lookup(name, dnsmessage.TypeNS) })
+	run(func() { caa, _ = ss.Resolver.Lookup(name, typeCAA) })
+	run(func() { txt = ss.lookupTXT(name) })
+	run(func() { dmarc = ss.lookupTXT("_dmarc." + name) })
+	wg.Wait()
+
+	for _, answer := range mx {
+		body := answer.Body.(*dnsmessage.MXResource)
+		result.MX = append(result.MX, MXRecord{
+			Host:       trimDot(body.MX.String()),
+			Preference: body.Pref,
+		})
+	}
+
+	for _, answer := range ns {
+		body := answer.Body.(*dnsmessage.NSResource)
+		result.NS = append(result.NS, trimDot(body.NS.String()))
+	}
+
+	for _, record := range txt {
+		result.TXT = append(result.TXT, record)
+		if strings.HasPrefix(strings.ToLower(record), "v=spf1") {
+			result.SPF = record
+		}
+	}
+
+	for _, record := range dmarc {
+		if strings.HasPrefix(strings.ToLower(record), "v=dmarc1") {
+			result.DMARC = record
+		}
+	}
+
+	for _, answer := range caa {
+		body, ok := answer.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		if record, ok := parseCAA(body.Data); ok {
+			result.CAA = append(result.CAA, record)
+		}
+	}
+
+	if len(result.MX) == 0 && len(result.NS) == 0 {
+		return
+	}
+	srv := make([][]dnsmessage.Resource, len(srvServices))
+	for i, service := range srvServices {
+		i, service := i, service
+		run(func() { srv[i], _ = ss.Resolver.Lookup(fmt.Sprintf("%s.%s", service, name), dnsmessage.TypeSRV) })
+	}
+	wg.Wait()
+
+	for i, answers := range srv {
+		for _, answer := range answers {
+			body := answer.Body.(*dnsmessage.SRVResource)
+			result.SRV = append(result.SRV, SRVRecord{
+				Service:  srvServices[i],
+				Target:   trimDot(body.Target.String()),
+				Port:     body.Port,
+				Priority: body.Priority,
+				Weight:   body.Weight,
+			})
+		}
+	}
+}
+
+// lookupTXT 查询TXT记录，同一记录的多个字符串片段会被拼接
+func (ss *SubdomainScanner) lookupTXT(name string) []string {
+	answers, err := ss.Resolver.Lookup(name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil
+	}
+
+	txts := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		body := answer.Body.(*dnsmessage.TXTResource)
+		txts = append(txts, strings.Join(body.TXT, ""))
+	}
+	return txts
+}
+
+// parseCAA 解析CAA记录的原始数据 (RFC 8659)
+func parseCAA(data []byte) (CAARecord, bool) {
+	if len(data) < 2 {
+		return CAARecord{}, false
+	}
+	tagLen := int(data[1])
+	if len(data) < 2+tagLen {
+		return CAARecord{}, false
+	}
+	return CAARecord{
+		Flag:  data[0],
+		Tag:   string(data[2 : 2+tagLen]),
+		Value: string(data[2+tagLen:]),
+	}, true
+}
+
+// trimDot 去掉域名末尾的点
+func trimDot(name string) string {
+	return strings.TrimSuffix(name, ".")
+}
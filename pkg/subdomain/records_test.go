@@ -0,0 +1,136 @@
+package subdomain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// caaData 构造CAA记录的原始数据
+func caaData(flag byte, tag, value string) []byte {
+	return append([]byte{flag, byte(len(tag))}, tag+value...)
+}
+
+// newRecordsStub 模拟mail.example.test的完整记录，plain.example.test只有A记录
+func newRecordsStub(t *testing.T) *dnsStub {
+	header := func(name string) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: mustName(name), Class: dnsmessage.ClassINET, TTL: 60}
+	}
+	return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		name := strings.TrimSuffix(q.Name.String(), ".")
+		answers := make([]dnsmessage.Resource, 0)
+		switch {
+		case name == "mail.example.test" && q.Type == dnsmessage.TypeMX:
+			answers = append(answers,
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.MXResource{Pref: 10, MX: mustName("mx1.example.test")}},
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.MXResource{Pref: 20, MX: mustName("mx2.example.test")}},
+			)
+		case name == "mail.example.test" && q.Type == dnsmessage.TypeTXT:
+			answers = append(answers,
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 include:_spf.example.test ", "-all"}}},
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.TXTResource{TXT: []string{"site-verification=abc"}}},
+			)
+		case name == "_dmarc.mail.example.test" && q.Type == dnsmessage.TypeTXT:
+			answers = append(answers, dnsmessage.Resource{Header: header(name), Body: &dnsmessage.TXTResource{TXT: []string{"v=DMARC1; p=reject"}}})
+		case name == "mail.example.test" && q.Type == typeCAA:
+			answers = append(answers,
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.UnknownResource{Type: typeCAA, Data: caaData(0, "issue", "letsencrypt.org")}},
+				dnsmessage.Resource{Header: header(name), Body: &dnsmessage.UnknownResource{Type: typeCAA, Data: caaData(128, "iodef", "mailto:sec@example.test")}},
+			)
+		case name == "_imaps._tcp.mail.example.test" && q.Type == dnsmessage.TypeSRV:
+			answers = append(answers, dnsmessage.Resource{Header: header(name), Body: &dnsmessage.SRVResource{Priority: 0, Weight: 5, Port: 993, Target: mustName("imap.example.test")}})
+		case name == "example.test" && q.Type == dnsmessage.TypeSOA:
+			answers = append(answers, soaRecord("example.test"))
+		case name == "plain.example.test" && q.Type == dnsmessage.TypeMX:
+			// 部分服务器在应答中附带其他类型的记录，Lookup只返回查询的类型
+			answers = append(answers, soaRecord("example.test"))
+		}
+		return []dnsmessage.Message{{Answers: answers}}
+	})
+}
+
+func TestCollectRecords(t *testing.T) {
+	stub := newRecordsStub(t)
+	ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+
+	result := &SubdomainResult{Subdomain: "mail.example.test"}
+	ss.collectRecords(result)
+
+	want := &SubdomainResult{
+		Subdomain: "mail.example.test",
+		MX:        []MXRecord{{Host: "mx1.example.test", Preference: 10}, {Host: "mx2.example.test", Preference: 20}},
+		TXT:       []string{"v=spf1 include:_spf.example.test -all", "site-verification=abc"},
+		SPF:       "v=spf1 include:_spf.example.test -all",
+		DMARC:     "v=DMARC1; p=reject",
+		SRV:       []SRVRecord{{Service: "_imaps._tcp", Target: "imap.example.test", Port: 993, Priority: 0, Weight: 5}},
+		CAA:       []CAARecord{{Flag: 0, Tag: "issue", Value: "letsencrypt.org"}, {Flag: 128, Tag: "iodef", Value: "mailto:sec@example.test"}},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("collectRecords() = %+v, want %+v", result, want)
+	}
+	if got, want := len(stub.Queries()), 5+len(srvServices); got != want {
+		t.Errorf("sent %d queries, want %d", got, want)
+	}
+}
+
+// TestCollectRecordsSkipsSRV 没有MX与NS记录的名称不探测SRV记录
+func TestCollectRecordsSkipsSRV(t *testing.T) {
+	stub := newRecordsStub(t)
+	ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+
+	result := &SubdomainResult{Subdomain: "plain.example.test"}
+	ss.collectRecords(result)
+	if !reflect.DeepEqual(result, &SubdomainResult{Subdomain: "plain.example.test"}) {
+		t.Errorf("collectRecords() = %+v, want no records", result)
+	}
+	for _, q := range stub.Queries() {
+		if q.Type == dnsmessage.TypeSRV {
+			t.Errorf("queried SRV %s on a name without MX or NS", q.Name)
+		}
+	}
+	if got := len(stub.Queries()); got != 5 {
+		t.Errorf("sent %d queries, want 5", got)
+	}
+}
+
+func TestResolverLookupSOA(t *testing.T) {
+	stub := newRecordsStub(t)
+	r := stubResolver(time.Second, 0, stub)
+
+	answers, err := r.Lookup("example.test", dnsmessage.TypeSOA)
+	if err != nil || len(answers) != 1 {
+		t.Fatalf("Lookup(SOA) = %v, %v, want one answer", answers, err)
+	}
+	soa := answers[0].Body.(*dnsmessage.SOAResource)
+	if soa.NS.String() != "ns1.example.test." || soa.MBox.String() != "hostmaster.example.test." || soa.Serial != 1 || soa.MinTTL != 60 {
+		t.Errorf("SOA = %+v", soa)
+	}
+
+	if _, err := r.Lookup("plain.example.test", dnsmessage.TypeMX); err != errNoAnswer {
+		t.Errorf("Lookup(MX) with only an SOA answer = %v, want errNoAnswer", err)
+	}
+}
+
+func TestParseCAA(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want CAARecord
+		ok   bool
+	}{
+		{caaData(0, "issue", "ca.test; account=1"), CAARecord{Flag: 0, Tag: "issue", Value: "ca.test; account=1"}, true},
+		{caaData(128, "issuewild", ";"), CAARecord{Flag: 128, Tag: "issuewild", Value: ";"}, true},
+		{caaData(0, "iodef", ""), CAARecord{Tag: "iodef"}, true},
+		{[]byte{0, 9, 'i', 's'}, CAARecord{}, false},
+		{[]byte{0}, CAARecord{}, false},
+		{nil, CAARecord{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCAA(tt.data)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseCAA(%v) = %+v %v, want %+v %v", tt.data, got, ok, tt.want, tt.ok)
+		}
+	}
+}
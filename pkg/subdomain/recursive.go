@@ -0,0 +1,51 @@
+package subdomain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recurse 以已发现的子域名为新的基础域名逐层爆破，直到没有新名称或达到最大层数
+// 每一层在爆破前都会单独检测泛解析，存在泛解析的基础域名下的名称无法区分真假，直接跳过
+func (ss *SubdomainScanner) recurse(state *scanState) {
+	words := ss.RecursiveWordlist
+	if words == nil {
+		words = ss.Wordlist
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	round := state.results
+	for len(round) > 0 {
+		candidates := make([]string, 0)
+		for _, result := range round {
+			if ss.depthOf(result.Subdomain) >= ss.MaxDepth {
+				continue
+			}
+			if info := ss.detectWildcard(result.Subdomain); info != nil {
+				ss.Logger.Warnning(fmt.Sprintf("Wildcard DNS detected on *.%s (IPs: %v, CNAMEs: %v), skip recursive brute-force", result.Subdomain, info.ipList(), info.cnameList()))
+				continue
+			}
+			for _, word := range words {
+				candidates = append(candidates, fmt.Sprintf("%s.%s", word, result.Subdomain))
+			}
+		}
+
+		candidates = state.untried(candidates)
+		if len(candidates) == 0 {
+			return
+		}
+		ss.Logger.Info(fmt.Sprintf("Recursive brute-force: resolving %d candidates", len(candidates)))
+		round = state.add(ss.resolveNames(candidates, SourceRecursive))
+	}
+}
+
+// depthOf 返回名称相对目标域名的标签层数，例如 a.b.example.com 为2
+func (ss *SubdomainScanner) depthOf(name string) int {
+	relative := strings.TrimSuffix(strings.ToLower(name), "."+strings.ToLower(ss.Domain))
+	if relative == "" || relative == strings.ToLower(name) {
+		return 0
+	}
+	return strings.Count(relative, ".") + 1
+}
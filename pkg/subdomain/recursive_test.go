@@ -0,0 +1,88 @@
+package subdomain
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newRecursiveStub 模拟 example.test 区域：dev、api.dev、www.api.dev 与 wild 存在，*.wild 为泛解析
+func newRecursiveStub(t *testing.T) *dnsStub {
+	hosts := map[string]string{
+		"dev.example.test":         "192.0.2.1",
+		"api.dev.example.test":     "192.0.2.2",
+		"www.api.dev.example.test": "192.0.2.3",
+		"wild.example.test":        "192.0.2.9",
+	}
+	return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		name := strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))
+		ip, ok := hosts[name]
+		if !ok && strings.HasSuffix(name, ".wild.example.test") {
+			ip, ok = "192.0.2.99", true
+		}
+		if !ok {
+			return answerRCode(dnsmessage.RCodeNameError)
+		}
+		if q.Type != dnsmessage.TypeA {
+			return answerRCode(dnsmessage.RCodeSuccess)
+		}
+		return answerA(q, ip)
+	})
+}
+
+func TestRecurse(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     []string
+	}{
+		{"depth 3", 3, []string{"api.dev.example.test", "dev.example.test", "wild.example.test", "www.api.dev.example.test"}},
+		{"depth 2", 2, []string{"api.dev.example.test", "dev.example.test", "wild.example.test"}},
+		{"depth 1", 1, []string{"dev.example.test", "wild.example.test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newRecursiveStub(t)
+			ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+			ss.SetConcurrent(4)
+			ss.SetWildcardTests(2)
+			ss.SetWordlist([]string{"dev", "api", "www", "wild"})
+			ss.SetRecursive(true, tt.maxDepth)
+
+			results := ss.Scan()
+			got := make([]string, 0, len(results))
+			for _, result := range results {
+				got = append(got, result.Subdomain)
+				wantSource := SourceRecursive
+				if ss.depthOf(result.Subdomain) == 1 {
+					wantSource = SourceBruteforce
+				}
+				if result.Source != wantSource {
+					t.Errorf("%s source = %s, want %s", result.Subdomain, result.Source, wantSource)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+
+			for _, q := range stub.Queries() {
+				name := strings.ToLower(strings.TrimSuffix(q.Name, "."))
+				// 泛解析的基础域名下只有随机标签的探测查询
+				for _, word := range ss.Wordlist {
+					if name == word+".wild.example.test" {
+						t.Errorf("brute-forced %s under a wildcard base", name)
+					}
+				}
+				// 爆破候选与泛解析探测都不会超过最大层数
+				if depth := ss.depthOf(name); depth > tt.maxDepth {
+					t.Errorf("queried %s at depth %d beyond max depth %d", name, depth, tt.maxDepth)
+				}
+			}
+		})
+	}
+}
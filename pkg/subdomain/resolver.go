@@ -0,0 +1,373 @@
+package subdomain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaung/nox/pkg/utils"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultResolvers 无法读取系统配置时使用的公共DNS服务器
+var defaultResolvers = []string{
+	"8.8.8.8:53",
+	"1.1.1.1:53",
+	"114.114.114.114:53",
+	"223.5.5.5:53",
+}
+
+var (
+	// errNoAnswer 查询成功但没有所需记录
+	errNoAnswer = errors.New("no answer")
+	// errNXDomain 域名不存在
+	errNXDomain = errors.New("no such domain")
+)
+
+// Resolver 原生DNS客户端，在多个DNS服务器之间轮询查询
+// 优先使用UDP查询，应答被截断时回退到TCP
+type Resolver struct {
+	Servers []string      // DNS服务器地址列表 (host:port)
+	Timeout time.Duration // 单次查询超时时间
+	Retries int           // 每个服务器的重试次数
+	Rate    int           // 每个服务器每秒最大查询次数，0表示不限制
+	DNSSEC  bool          // 查询时设置DO标志位，用于获取NSEC等DNSSEC记录
+
+	next      uint32                        // 下一次查询使用的服务器下标
+	limiters  map[string]*utils.RateLimiter // 各服务器的限速器
+	limiterMu sync.Mutex                    // 保护limiters
+}
+
+// NewResolver 创建一个新的DNS客户端实例
+func NewResolver(servers []string) *Resolver {
+	return &Resolver{
+		Servers:  servers,
+		Timeout:  time.Second * 2,
+		Retries:  2,
+		limiters: make(map[string]*utils.RateLimiter),
+	}
+}
+
+// LoadResolvers 从文件中读取DNS服务器列表，每行一个，支持#注释，未指定端口时默认53
+func LoadResolvers(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open resolvers file: %v", err)
+	}
+	defer file.Close()
+
+	servers := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		servers = append(servers, withDefaultPort(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read resolvers file: %v", err)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no resolvers found in %s", path)
+	}
+
+	return servers, nil
+}
+
+// SystemResolvers 读取/etc/resolv.conf中的DNS服务器，读取失败时返回公共DNS服务器
+func SystemResolvers() []string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return defaultResolvers
+	}
+	defer file.Close()
+
+	servers := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, withDefaultPort(fields[1]))
+		}
+	}
+	if len(servers) == 0 {
+		return defaultResolvers
+	}
+
+	return servers
+}
+
+// withDefaultPort 为未指定端口的服务器地址补全53端口
+func withDefaultPort(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+// limiter 获取指定服务器的限速器，未开启限速时返回nil
+func (r *Resolver) limiter(server string) *utils.RateLimiter {
+	if r.Rate <= 0 {
+		return nil
+	}
+
+	r.limiterMu.Lock()
+	defer r.limiterMu.Unlock()
+	if r.limiters == nil {
+		r.limiters = make(map[string]*utils.RateLimiter)
+	}
+	rl, ok := r.limiters[server]
+	if !ok {
+		rl = utils.NewRateLimiter(time.Second / time.Duration(r.Rate))
+		r.limiters[server] = rl
+	}
+	return rl
+}
+
+// Exchange 查询指定名称的指定记录类型
+// 按轮询顺序选择服务器，每个服务器最多重试Retries次，服务器失败或拒绝时切换到下一个
+func (r *Resolver) Exchange(name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	if len(r.Servers) == 0 {
+		return nil, fmt.Errorf("no resolvers configured")
+	}
+
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain name %q: %v", name, err)
+	}
+
+	start := int(atomic.AddUint32(&r.next, 1))
+	var lastErr error
+	for i := 0; i < len(r.Servers); i++ {
+		server := r.Servers[(start+i)%len(r.Servers)]
+		for attempt := 0; attempt <= r.Retries; attempt++ {
+			if rl := r.limiter(server); rl != nil {
+				rl.Wait()
+			}
+
+			msg, err := r.exchange(server, qname, qtype)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			// 服务器故障或拒绝服务时换下一个服务器
+			if msg.RCode == dnsmessage.RCodeServerFailure || msg.RCode == dnsmessage.RCodeRefused {
+				lastErr = fmt.Errorf("%s answered %s", server, msg.RCode)
+				break
+			}
+			return msg, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+// exchange 向单个服务器发送查询，UDP应答被截断时回退到TCP
+func (r *Resolver) exchange(server string, qname dnsmessage.Name, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildQuery(id, qname, qtype, r.DNSSEC)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := r.exchangeUDP(server, id, query)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Truncated {
+		return r.exchangeTCP(server, id, query)
+	}
+	return msg, nil
+}
+
+// exchangeUDP 通过UDP发送查询并等待ID匹配的应答
+func (r *Resolver) exchangeUDP(server string, id uint16, query []byte) (*dnsmessage.Message, error) {
+	conn, err := net.DialTimeout("udp", server, r.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %v", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(r.Timeout))
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, fmt.Errorf("failed to send query to %s: %v", server, err)
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answer from %s: %v", server, err)
+		}
+
+		msg := new(dnsmessage.Message)
+		if err := msg.Unpack(buf[:n]); err != nil || msg.ID != id || !msg.Response {
+			// 忽略无法解析或不匹配的报文，继续等待
+			continue
+		}
+		return msg, nil
+	}
+}
+
+// exchangeTCP 通过TCP发送查询
+func (r *Resolver) exchangeTCP(server string, id uint16, query []byte) (*dnsmessage.Message, error) {
+	conn, err := net.DialTimeout("tcp", server, r.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %v", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(r.Timeout))
+
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, fmt.Errorf("failed to send query to %s: %v", server, err)
+	}
+
+	raw, err := readTCPMessage(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answer from %s: %v", server, err)
+	}
+
+	msg := new(dnsmessage.Message)
+	if err := msg.Unpack(raw); err != nil {
+		return nil, fmt.Errorf("failed to parse answer from %s: %v", server, err)
+	}
+	if msg.ID != id {
+		return nil, fmt.Errorf("mismatched answer id from %s", server)
+	}
+	return msg, nil
+}
+
+// LookupHost 查询名称的A与AAAA记录，返回IP列表与CNAME链
+// 名称或CNAME目标不存在时返回errNXDomain，此时仍会返回已解析到的CNAME链
+func (r *Resolver) LookupHost(name string) ([]string, []string, error) {
+	ips := make([]string, 0)
+	var chain []string
+	var lastErr error
+
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		msg, err := r.Exchange(name, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if msg.RCode == dnsmessage.RCodeNameError {
+			return nil, cnameChain(msg, name), fmt.Errorf("%s: %w", name, errNXDomain)
+		}
+
+		if links := cnameChain(msg, name); len(links) > len(chain) {
+			chain = links
+		}
+		for _, answer := range msg.Answers {
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				ips = append(ips, net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				ips = append(ips, net.IP(body.AAAA[:]).String())
+			}
+		}
+	}
+
+	if len(ips) == 0 {
+		if lastErr != nil {
+			return nil, chain, lastErr
+		}
+		return nil, chain, errNoAnswer
+	}
+	return ips, chain, nil
+}
+
+// buildQuery 构造一个开启递归并携带EDNS0的查询报文
+func buildQuery(id uint16, qname dnsmessage.Name, qtype dnsmessage.Type, dnssec bool) ([]byte, error) {
+	builder := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{
+		ID:               id,
+		RecursionDesired: true,
+	})
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(dnsmessage.Question{
+		Name:  qname,
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, dnssec); err != nil {
+		return nil, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+
+	return builder.Finish()
+}
+
+// writeTCPMessage 写入带两字节长度前缀的DNS报文
+func writeTCPMessage(conn net.Conn, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := conn.Write(buf)
+	return err
+}
+
+// readTCPMessage 读取一个带两字节长度前缀的DNS报文
+func readTCPMessage(conn net.Conn) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// cnameChain 从应答中提取以name开始的CNAME链
+func cnameChain(msg *dnsmessage.Message, name string) []string {
+	chain := make([]string, 0)
+	current := fqdn(name)
+	for hop := 0; hop < 16; hop++ {
+		next := ""
+		for _, answer := range msg.Answers {
+			body, ok := answer.Body.(*dnsmessage.CNAMEResource)
+			if ok && strings.EqualFold(answer.Header.Name.String(), current) {
+				next = body.CNAME.String()
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		chain = append(chain, trimDot(next))
+		current = next
+	}
+	return chain
+}
+
+// fqdn 返回以点结尾的完整域名
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,175 @@
+package subdomain
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// answerA 返回包含一条A记录的应答
+func answerA(q dnsmessage.Question, ip string) []dnsmessage.Message {
+	return []dnsmessage.Message{{Answers: []dnsmessage.Resource{aRecord(q.Name.String(), ip)}}}
+}
+
+// answerRCode 返回只有应答码的应答
+func answerRCode(rcode dnsmessage.RCode) []dnsmessage.Message {
+	return []dnsmessage.Message{{Header: dnsmessage.Header{RCode: rcode}}}
+}
+
+// countQueries 统计测试服务器收到的UDP与TCP查询数量
+func countQueries(stub *dnsStub) (udp, tcp int) {
+	for _, q := range stub.Queries() {
+		if q.TCP {
+			tcp++
+		} else {
+			udp++
+		}
+	}
+	return udp, tcp
+}
+
+// TestResolverTruncatedFallback UDP应答被截断时通过TCP重新查询
+func TestResolverTruncatedFallback(t *testing.T) {
+	stub := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		if !tcp {
+			return []dnsmessage.Message{{Header: dnsmessage.Header{Truncated: true}}}
+		}
+		return answerA(q, "192.0.2.10")
+	})
+
+	msg, err := stubResolver(time.Second, 0, stub).Exchange("www.example.com", dnsmessage.TypeA)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if msg.Truncated || len(msg.Answers) != 1 {
+		t.Fatalf("answer = %+v, want one untruncated answer", msg)
+	}
+	if a := msg.Answers[0].Body.(*dnsmessage.AResource).A; a != [4]byte{192, 0, 2, 10} {
+		t.Errorf("A = %v, want 192.0.2.10", a)
+	}
+	if udp, tcp := countQueries(stub); udp != 1 || tcp != 1 {
+		t.Errorf("got %d udp and %d tcp queries, want 1 and 1", udp, tcp)
+	}
+}
+
+// TestResolverNextServer 服务器故障或拒绝时不重试，直接换下一个服务器
+func TestResolverNextServer(t *testing.T) {
+	for _, rcode := range []dnsmessage.RCode{dnsmessage.RCodeServerFailure, dnsmessage.RCodeRefused} {
+		t.Run(rcode.String(), func(t *testing.T) {
+			bad := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+				return answerRCode(rcode)
+			})
+			good := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+				return answerA(q, "192.0.2.20")
+			})
+
+			msg, err := stubResolver(time.Second, 2, bad, good).Exchange("www.example.com", dnsmessage.TypeA)
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if msg.RCode != dnsmessage.RCodeSuccess || len(msg.Answers) != 1 {
+				t.Errorf("answer = %+v, want one answer from the next server", msg)
+			}
+			if got := len(bad.Queries()); got != 1 {
+				t.Errorf("failing server queried %d times, want 1", got)
+			}
+			if got := len(good.Queries()); got != 1 {
+				t.Errorf("next server queried %d times, want 1", got)
+			}
+		})
+	}
+
+	t.Run("all servers fail", func(t *testing.T) {
+		bad := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+			return answerRCode(dnsmessage.RCodeRefused)
+		})
+		if _, err := stubResolver(time.Second, 2, bad, bad).Exchange("www.example.com", dnsmessage.TypeA); err == nil {
+			t.Error("Exchange succeeded, want error")
+		}
+	})
+}
+
+// TestResolverRetries 查询超时后对同一服务器最多重试Retries次
+func TestResolverRetries(t *testing.T) {
+	t.Run("exhausted", func(t *testing.T) {
+		silent := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+			return nil
+		})
+		other := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+			return nil
+		})
+		if _, err := stubResolver(50*time.Millisecond, 2, silent, other).Exchange("www.example.com", dnsmessage.TypeA); err == nil {
+			t.Fatal("Exchange succeeded, want error")
+		}
+		if got := len(silent.Queries()); got != 3 {
+			t.Errorf("first server queried %d times, want 3", got)
+		}
+		if got := len(other.Queries()); got != 3 {
+			t.Errorf("second server queried %d times, want 3", got)
+		}
+	})
+
+	t.Run("answered on last retry", func(t *testing.T) {
+		var count int32
+		flaky := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+			if atomic.AddInt32(&count, 1) < 3 {
+				return nil
+			}
+			return answerA(q, "192.0.2.30")
+		})
+		if _, err := stubResolver(50*time.Millisecond, 2, flaky).Exchange("www.example.com", dnsmessage.TypeA); err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+		if got := len(flaky.Queries()); got != 3 {
+			t.Errorf("server queried %d times, want 3", got)
+		}
+	})
+}
+
+// TestResolverTimeout 服务器不应答时每次查询在Timeout后放弃
+func TestResolverTimeout(t *testing.T) {
+	silent := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		return nil
+	})
+
+	const timeout = 200 * time.Millisecond
+	start := time.Now()
+	_, err := stubResolver(timeout, 1, silent).Exchange("www.example.com", dnsmessage.TypeA)
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("Exchange succeeded, want timeout error")
+	}
+	// 1次查询加1次重试
+	if elapsed < 2*timeout || elapsed > 2*timeout+time.Second {
+		t.Errorf("Exchange returned after %s, want about %s", elapsed, 2*timeout)
+	}
+}
+
+// TestResolverRate 开启限速后同一服务器的查询间隔不小于 1/Rate 秒
+func TestResolverRate(t *testing.T) {
+	stub := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		return answerA(q, "192.0.2.40")
+	})
+	r := stubResolver(time.Second, 0, stub)
+	r.Rate = 10
+
+	for i := 0; i < 4; i++ {
+		if _, err := r.Exchange("www.example.com", dnsmessage.TypeA); err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+	}
+
+	queries := stub.Queries()
+	if len(queries) != 4 {
+		t.Fatalf("server queried %d times, want 4", len(queries))
+	}
+	// 查询发出后到达测试服务器的时间略有抖动，留出少量余量
+	interval := time.Second / time.Duration(r.Rate)
+	for i := 1; i < len(queries); i++ {
+		if gap := queries[i].At.Sub(queries[i-1].At); gap < interval-10*time.Millisecond {
+			t.Errorf("queries %d and %d were %s apart, want at least %s", i-1, i, gap, interval)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package subdomain
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hostnamePattern 文本中的主机名
+var hostnamePattern = regexp.MustCompile(`(?i)(?:\*\.)?(?:[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}`)
+
+// Source 被动子域名来源，返回的名称会经过主动解析验证后才会计入结果
+type Source interface {
+	Name() string                               // 来源名称，同时作为结果的发现来源
+	Subdomains(domain string) ([]string, error) // 返回目标域名下的候选名称
+}
+
+// CTExportSource 证书透明度导出文件，支持PEM证书或任意包含主机名的文本
+type CTExportSource struct {
+	Path string // 文件路径
+}
+
+// DatasetSource DNS数据集文件，支持JSON行格式(包含name/value字段)、CSV或纯文本，.gz结尾时自动解压
+type DatasetSource struct {
+	Path string // 文件路径
+}
+
+// NoxResultSource 此前Nox以JSON格式输出的子域名扫描结果
+type NoxResultSource struct {
+	Path string // 文件路径
+}
+
+// CrtshFileSource 保存在磁盘上的crt.sh JSON查询结果
+type CrtshFileSource struct {
+	Path string // 文件路径
+}
+
+// CrtshSource 通过crt.sh兼容的HTTP接口查询证书透明度日志
+type CrtshSource struct {
+	BaseURL string        // 接口地址，可指向内部镜像或模拟服务
+	Timeout time.Duration // 请求超时时间
+}
+
+// crtshEntry crt.sh JSON结果中的一条记录
+type crtshEntry struct {
+	CommonName string `json:"common_name"`
+	NameValue  string `json:"name_value"`
+}
+
+// NewCrtshSource 创建一个crt.sh来源，baseURL为空时使用官方地址
+func NewCrtshSource(baseURL string) *CrtshSource {
+	if baseURL == "" {
+		baseURL = "https://crt.sh"
+	}
+	return &CrtshSource{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Timeout: time.Second * 30,
+	}
+}
+
+// ParseSource 解析形如 kind:value 的来源描述
+// 支持 ct:文件、dataset:文件、nox:文件、crtsh-file:文件 以及 crtsh[:接口地址]
+func ParseSource(spec string) (Source, error) {
+	kind, value, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "ct":
+		return &CTExportSource{Path: value}, nil
+	case "dataset":
+		return &DatasetSource{Path: value}, nil
+	case "nox":
+		return &NoxResultSource{Path: value}, nil
+	case "crtsh-file":
+		return &CrtshFileSource{Path: value}, nil
+	case "crtsh":
+		return NewCrtshSource(value), nil
+	}
+	return nil, fmt.Errorf("unknown source %q", spec)
+}
+
+// Name 返回来源名称
+func (s *CTExportSource) Name() string {
+	return "ct"
+}
+
+// Subdomains 从证书的SAN与CN中提取名称，非PEM内容按文本处理
+func (s *CTExportSource) Subdomains(domain string) ([]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CT export: %v", err)
+	}
+
+	names := make([]string, 0)
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		names = append(names, cert.Subject.CommonName)
+		names = append(names, cert.DNSNames...)
+	}
+	if len(names) == 0 {
+		names = hostnamePattern.FindAllString(string(data), -1)
+	}
+
+	return filterNames(names, domain), nil
+}
+
+// Name 返回来源名称
+func (s *DatasetSource) Name() string {
+	return "dataset"
+}
+
+// Subdomains 逐行读取数据集，JSON行取name与value字段，其余行提取其中的主机名
+func (s *DatasetSource) Subdomains(domain string) ([]string, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %v", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(s.Path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress dataset: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	// 只保留包含目标域名的行，避免超大数据集占用过多内存
+	needle := strings.ToLower(domain)
+	names := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(strings.ToLower(line), needle) {
+			continue
+		}
+
+		var record struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "{") && json.Unmarshal([]byte(line), &record) == nil {
+			names = append(names, record.Name, record.Value)
+			continue
+		}
+		names = append(names, hostnamePattern.FindAllString(line, -1)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %v", err)
+	}
+
+	return filterNames(names, domain), nil
+}
+
+// Name 返回来源名称
+func (s *NoxResultSource) Name() string {
+	return "nox"
+}
+
+// Subdomains 读取此前扫描结果中的子域名
+func (s *NoxResultSource) Subdomains(domain string) ([]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nox results: %v", err)
+	}
+
+	results := make([]SubdomainResult, 0)
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse nox results: %v", err)
+	}
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Subdomain)
+		names = append(names, result.CNAMEs...)
+	}
+	return filterNames(names, domain), nil
+}
+
+// Name 返回来源名称
+func (s *CrtshFileSource) Name() string {
+	return "crtsh-file"
+}
+
+// Subdomains 读取crt.sh JSON结果中的名称
+func (s *CrtshFileSource) Subdomains(domain string) ([]string, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crt.sh file: %v", err)
+	}
+	defer file.Close()
+
+	return parseCrtsh(file, domain)
+}
+
+// Name 返回来源名称
+func (s *CrtshSource) Name() string {
+	return "crtsh"
+}
+
+// Subdomains 查询crt.sh接口中目标域名的证书记录
+func (s *CrtshSource) Subdomains(domain string) ([]string, error) {
+	client := &http.Client{
+		Timeout: s.Timeout,
+	}
+
+	query := url.Values{}
+	query.Set("q", "%."+domain)
+	query.Set("output", "json")
+	resp, err := client.Get(fmt.Sprintf("%s/?%s", s.BaseURL, query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crt.sh: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("crt.sh answered %s", resp.Status)
+	}
+	return parseCrtsh(resp.Body, domain)
+}
+
+// parseCrtsh 解析crt.sh JSON结果，name_value中可能包含多个以换行分隔的名称
+func parseCrtsh(reader io.Reader, domain string) ([]string, error) {
+	entries := make([]crtshEntry, 0)
+	if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse crt.sh results: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.CommonName)
+		names = append(names, strings.Split(entry.NameValue, "\n")...)
+	}
+	return filterNames(names, domain), nil
+}
+
+// filterNames 规范化名称并只保留目标域名下的子域名，去掉通配符前缀与重复项
+func filterNames(names []string, domain string) []string {
+	suffix := "." + strings.ToLower(domain)
+	seen := make(map[string]bool)
+	filtered := make([]string, 0)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "*."), ".")
+		if !strings.HasSuffix(name, suffix) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		filtered = append(filtered, name)
+	}
+	return filtered
+}
@@ -0,0 +1,202 @@
+package subdomain
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile 在临时目录中写入测试文件并返回路径
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+const crtshBody = `[
+	{"common_name": "www.example.com", "name_value": "www.example.com\n*.api.example.com"},
+	{"common_name": "Mail.Example.COM.", "name_value": "mail.example.com\nexample.org\nnotexample.com"},
+	{"common_name": "example.com", "name_value": "www.example.com"}
+]`
+
+func TestFilterNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{
+			name:  "wildcard prefix",
+			names: []string{"*.api.example.com", "*.example.com"},
+			want:  []string{"api.example.com"},
+		},
+		{
+			name:  "case and trailing dot",
+			names: []string{" WWW.Example.com. ", "www.example.com"},
+			want:  []string{"www.example.com"},
+		},
+		{
+			name:  "outside the domain",
+			names: []string{"example.com", "example.org", "badexample.com", "example.com.evil.net", "a.b.example.com"},
+			want:  []string{"a.b.example.com"},
+		},
+		{
+			name:  "empty",
+			names: []string{"", "*."},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterNames(tt.names, "Example.com"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterNames(%q) = %q, want %q", tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCrtsh(t *testing.T) {
+	got, err := parseCrtsh(strings.NewReader(crtshBody), "example.com")
+	if err != nil {
+		t.Fatalf("parseCrtsh: %v", err)
+	}
+	want := []string{"www.example.com", "api.example.com", "mail.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCrtsh() = %q, want %q", got, want)
+	}
+
+	if _, err := parseCrtsh(strings.NewReader("<html>rate limited</html>"), "example.com"); err == nil {
+		t.Error("parseCrtsh accepted a non-JSON body")
+	}
+}
+
+func TestCrtshSource(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Query().Get("q") == "%.down.example" {
+			http.Error(w, "busy", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(crtshBody))
+	}))
+	defer server.Close()
+
+	source := NewCrtshSource(server.URL + "/")
+	got, err := source.Subdomains("example.com")
+	if err != nil {
+		t.Fatalf("Subdomains: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Subdomains() = %q, want 3 names", got)
+	}
+	if query != "output=json&q=%25.example.com" {
+		t.Errorf("query = %q, want output=json&q=%%25.example.com", query)
+	}
+
+	if _, err := source.Subdomains("down.example"); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Subdomains on 502 returned %v, want status error", err)
+	}
+}
+
+func TestDatasetSource(t *testing.T) {
+	content := strings.Join([]string{
+		`{"name": "dev.example.com", "type": "cname", "value": "cdn.example.com"}`,
+		`{"name": "other.org", "type": "a", "value": "1.2.3.4"}`,
+		`vpn.example.com,A,10.0.0.1`,
+		`see https://Portal.Example.com/login for details`,
+		`unrelated.net`,
+	}, "\n")
+
+	want := []string{"dev.example.com", "cdn.example.com", "vpn.example.com", "portal.example.com"}
+
+	plain := writeFile(t, "dataset.txt", content)
+	got, err := (&DatasetSource{Path: plain}).Subdomains("example.com")
+	if err != nil {
+		t.Fatalf("Subdomains: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Subdomains() = %q, want %q", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "dataset.json.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(content))
+	gz.Close()
+	file.Close()
+
+	got, err = (&DatasetSource{Path: path}).Subdomains("example.com")
+	if err != nil {
+		t.Fatalf("Subdomains(gzip): %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Subdomains(gzip) = %q, want %q", got, want)
+	}
+
+	if _, err := (&DatasetSource{Path: plain + ".gz"}).Subdomains("example.com"); err == nil {
+		t.Error("Subdomains accepted a missing file")
+	}
+}
+
+func TestNoxResultSource(t *testing.T) {
+	path := writeFile(t, "nox.json", `[
+		{"Subdomain": "www.example.com", "IPList": ["192.0.2.1"], "CNAMEs": ["edge.example.com", "edge.cdn.net"]},
+		{"Subdomain": "shop.example.com"}
+	]`)
+	got, err := (&NoxResultSource{Path: path}).Subdomains("example.com")
+	if err != nil {
+		t.Fatalf("Subdomains: %v", err)
+	}
+	want := []string{"www.example.com", "edge.example.com", "shop.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Subdomains() = %q, want %q", got, want)
+	}
+
+	broken := writeFile(t, "broken.json", "www.example.com")
+	if _, err := (&NoxResultSource{Path: broken}).Subdomains("example.com"); err == nil {
+		t.Error("Subdomains accepted a non-JSON result file")
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Source
+	}{
+		{spec: "ct:certs.pem", want: &CTExportSource{Path: "certs.pem"}},
+		{spec: "dataset:fdns.json.gz", want: &DatasetSource{Path: "fdns.json.gz"}},
+		{spec: "nox:old.json", want: &NoxResultSource{Path: "old.json"}},
+		{spec: "crtsh-file:crt.json", want: &CrtshFileSource{Path: "crt.json"}},
+		{spec: "crtsh", want: NewCrtshSource("")},
+		{spec: "crtsh:http://127.0.0.1:8080/", want: NewCrtshSource("http://127.0.0.1:8080")},
+	}
+	for _, tt := range tests {
+		got, err := ParseSource(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSource(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSource(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "certs.pem", "shodan:key", "CT:certs.pem"} {
+		if _, err := ParseSource(spec); err == nil {
+			t.Errorf("ParseSource(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package subdomain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 接管风险等级
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+//go:embed takeover.json
+var takeoverData []byte
+
+var (
+	defaultSignatures     []TakeoverSignature // 解析后的内置特征，只读
+	defaultSignaturesOnce sync.Once
+)
+
+// TakeoverSignature 子域名接管特征
+type TakeoverSignature struct {
+	Service     string   `json:"service"`     // 云服务/SaaS名称
+	CNAME       []string `json:"cname"`       // CNAME目标后缀
+	Fingerprint []string `json:"fingerprint"` // 未认领资源的HTTP响应特征
+	NXDomain    bool     `json:"nxdomain"`    // CNAME目标不存在即可认领
+	Severity    string   `json:"severity"`    // 风险等级
+}
+
+// TakeoverFinding 子域名接管检测结果
+type TakeoverFinding struct {
+	Subdomain string // 子域名
+	Target    string // CNAME链末端的目标
+	Service   string // 命中的服务，未知服务时为空
+	Severity  string // 风险等级
+	Evidence  string // 判定依据
+}
+
+// DefaultTakeoverSignatures 返回内置的子域名接管特征
+// 内置特征随程序一起编译，无法解析时说明特征文件有误，直接panic而不是返回空列表
+func DefaultTakeoverSignatures() []TakeoverSignature {
+	return append([]TakeoverSignature(nil), defaultTakeoverSignatures()...)
+}
+
+// defaultTakeoverSignatures 返回只解析一次的内置特征，调用方不能修改
+func defaultTakeoverSignatures() []TakeoverSignature {
+	defaultSignaturesOnce.Do(func() {
+		signatures, err := parseTakeoverSignatures(takeoverData)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded takeover signatures: %v", err))
+		}
+		defaultSignatures = signatures
+	})
+	return defaultSignatures
+}
+
+// LoadTakeoverSignatures 从JSON文件中读取子域名接管特征
+func LoadTakeoverSignatures(path string) ([]TakeoverSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read takeover signatures: %v", err)
+	}
+	return parseTakeoverSignatures(data)
+}
+
+// parseTakeoverSignatures 解析子域名接管特征
+func parseTakeoverSignatures(data []byte) ([]TakeoverSignature, error) {
+	signatures := make([]TakeoverSignature, 0)
+	if err := json.Unmarshal(data, &signatures); err != nil {
+		return nil, fmt.Errorf("failed to parse takeover signatures: %v", err)
+	}
+	for i := range signatures {
+		if signatures[i].Severity == "" {
+			signatures[i].Severity = SeverityHigh
+		}
+	}
+	return signatures, nil
+}
+
+// matchCNAME 判断目标是否命中特征的CNAME后缀
+func (sig *TakeoverSignature) matchCNAME(target string) bool {
+	target = strings.ToLower(target)
+	for _, suffix := range sig.CNAME {
+		suffix = strings.ToLower(strings.Trim(suffix, "."))
+		if target == suffix || strings.HasSuffix(target, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTakeover 沿CNAME链检测悬挂记录
+// CNAME目标不存在、或HTTP响应命中服务商"资源不存在"特征时记录为接管风险
+func (ss *SubdomainScanner) checkTakeover(result *SubdomainResult) {
+	if len(result.CNAMEs) == 0 {
+		return
+	}
+
+	signatures := ss.TakeoverSignatures
+	if signatures == nil {
+		signatures = defaultTakeoverSignatures()
+	}
+
+	target := result.CNAMEs[len(result.CNAMEs)-1]
+	var matched []*TakeoverSignature
+	for i := range signatures {
+		for _, cname := range result.CNAMEs {
+			if signatures[i].matchCNAME(cname) {
+				matched = append(matched, &signatures[i])
+				break
+			}
+		}
+	}
+
+	// CNAME目标不存在，没有命中可认领服务的特征时只作为低风险的悬挂记录
+	_, _, err := ss.Resolver.LookupHost(target)
+	if errors.Is(err, errNXDomain) {
+		finding := TakeoverFinding{
+			Subdomain: result.Subdomain,
+			Target:    target,
+			Severity:  SeverityLow,
+			Evidence:  fmt.Sprintf("CNAME target %s returned NXDOMAIN (no matching signature)", target),
+		}
+		for _, sig := range matched {
+			if sig.NXDomain {
+				finding.Service = sig.Service
+				finding.Severity = sig.Severity
+				finding.Evidence = fmt.Sprintf("CNAME target %s returned NXDOMAIN", target)
+				break
+			}
+		}
+		result.Takeovers = append(result.Takeovers, finding)
+		return
+	}
+
+	// 未认领资源的HTTP响应特征
+	if len(matched) == 0 {
+		return
+	}
+	body, pageURL := ss.fetchPage(result.Subdomain)
+	if body == "" {
+		return
+	}
+	for _, sig := range matched {
+		for _, fingerprint := range sig.Fingerprint {
+			if strings.Contains(body, fingerprint) {
+				result.Takeovers = append(result.Takeovers, TakeoverFinding{
+					Subdomain: result.Subdomain,
+					Target:    target,
+					Service:   sig.Service,
+					Severity:  sig.Severity,
+					Evidence:  fmt.Sprintf("%s matched %q", pageURL, fingerprint),
+				})
+				return
+			}
+		}
+	}
+}
+
+// fetchPage 依次尝试HTTPS与HTTP获取子域名首页，返回响应内容与实际请求的URL
+func (ss *SubdomainScanner) fetchPage(host string) (string, string) {
+	client := &http.Client{
+		Timeout: ss.Timeout,
+	}
+
+	for _, scheme := range []string{"https", "http"} {
+		pageURL := fmt.Sprintf("%s://%s/", scheme, host)
+		resp, err := client.Get(pageURL)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		return string(body), pageURL
+	}
+
+	return "", ""
+}
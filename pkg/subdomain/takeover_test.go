@@ -0,0 +1,131 @@
+package subdomain
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TestEmbeddedTakeoverSignatures 确认内置的子域名接管特征能被解析且字段完整
+func TestEmbeddedTakeoverSignatures(t *testing.T) {
+	signatures, err := parseTakeoverSignatures(takeoverData)
+	if err != nil {
+		t.Fatalf("failed to parse embedded takeover signatures: %v", err)
+	}
+	if len(signatures) == 0 {
+		t.Fatal("no embedded takeover signatures")
+	}
+
+	for _, sig := range signatures {
+		if sig.Service == "" {
+			t.Errorf("signature without service: %+v", sig)
+		}
+		if len(sig.CNAME) == 0 {
+			t.Errorf("%s: no cname suffixes", sig.Service)
+		}
+		if len(sig.Fingerprint) == 0 && !sig.NXDomain {
+			t.Errorf("%s: neither fingerprint nor nxdomain", sig.Service)
+		}
+		switch sig.Severity {
+		case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow:
+		default:
+			t.Errorf("%s: unknown severity %q", sig.Service, sig.Severity)
+		}
+	}
+
+	if got := len(DefaultTakeoverSignatures()); got != len(signatures) {
+		t.Errorf("DefaultTakeoverSignatures() returned %d signatures, want %d", got, len(signatures))
+	}
+}
+
+// TestCheckTakeover 使用DNS测试服务器与HTTP测试站点检测悬挂的CNAME记录
+func TestCheckTakeover(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h1>There is no site here</h1>"))
+	}))
+	defer page.Close()
+	host := strings.TrimPrefix(page.URL, "http://")
+
+	// 只有site.pages.test能解析，其他CNAME目标都不存在
+	stub := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		if q.Name.String() == "site.pages.test." && q.Type == dnsmessage.TypeA {
+			return answerA(q, "127.0.0.1")
+		}
+		if q.Name.String() == "site.pages.test." {
+			return answerRCode(dnsmessage.RCodeSuccess)
+		}
+		return answerRCode(dnsmessage.RCodeNameError)
+	})
+
+	signatures := []TakeoverSignature{
+		{Service: "Example Cloud", CNAME: []string{"cloud.test"}, NXDomain: true, Severity: SeverityCritical},
+		{Service: "Example Pages", CNAME: []string{"pages.test"}, Fingerprint: []string{"There is no site here"}, Severity: SeverityHigh},
+	}
+
+	tests := []struct {
+		name      string
+		subdomain string
+		cnames    []string
+		want      []TakeoverFinding
+	}{
+		{
+			name:      "nxdomain with signature",
+			subdomain: "app.example.com",
+			cnames:    []string{"app.cloud.test"},
+			want: []TakeoverFinding{{
+				Subdomain: "app.example.com",
+				Target:    "app.cloud.test",
+				Service:   "Example Cloud",
+				Severity:  SeverityCritical,
+				Evidence:  "CNAME target app.cloud.test returned NXDOMAIN",
+			}},
+		},
+		{
+			name:      "nxdomain without signature",
+			subdomain: "old.example.com",
+			cnames:    []string{"old.example.net", "gone.unknown.test"},
+			want: []TakeoverFinding{{
+				Subdomain: "old.example.com",
+				Target:    "gone.unknown.test",
+				Severity:  SeverityLow,
+				Evidence:  "CNAME target gone.unknown.test returned NXDOMAIN (no matching signature)",
+			}},
+		},
+		{
+			name:      "http body match",
+			subdomain: host,
+			cnames:    []string{"site.pages.test"},
+			want: []TakeoverFinding{{
+				Subdomain: host,
+				Target:    "site.pages.test",
+				Service:   "Example Pages",
+				Severity:  SeverityHigh,
+				Evidence:  fmt.Sprintf("http://%s/ matched %q", host, "There is no site here"),
+			}},
+		},
+		{
+			name:      "no cname",
+			subdomain: "www.example.com",
+		},
+	}
+
+	ss := NewSubdomainScanner("example.com")
+	ss.Timeout = time.Second
+	ss.Resolver = stubResolver(time.Second, 0, stub)
+	ss.SetTakeoverSignatures(signatures)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &SubdomainResult{Subdomain: tt.subdomain, CNAMEs: tt.cnames}
+			ss.checkTakeover(result)
+			if !reflect.DeepEqual(result.Takeovers, tt.want) {
+				t.Errorf("Takeovers = %+v, want %+v", result.Takeovers, tt.want)
+			}
+		})
+	}
+}
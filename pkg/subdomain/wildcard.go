@@ -0,0 +1,128 @@
+package subdomain
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// wildcardInfo 某一层级的泛解析应答
+type wildcardInfo struct {
+	IPs    map[string]struct{} // 随机标签解析到的IP集合
+	CNAMEs map[string]struct{} // 随机标签解析到的CNAME集合
+}
+
+// ipList 返回排序后的泛解析IP列表
+func (wi *wildcardInfo) ipList() []string {
+	return sortedKeys(wi.IPs)
+}
+
+// cnameList 返回排序后的泛解析CNAME列表
+func (wi *wildcardInfo) cnameList() []string {
+	return sortedKeys(wi.CNAMEs)
+}
+
+// matches 判断结果是否与泛解析应答一致
+// 首个CNAME命中泛解析CNAME链，或全部IP都落在泛解析IP集合内时视为泛解析结果
+func (wi *wildcardInfo) matches(result *SubdomainResult) bool {
+	if len(result.CNAMEs) > 0 {
+		if _, ok := wi.CNAMEs[strings.ToLower(result.CNAMEs[0])]; ok {
+			return true
+		}
+	}
+
+	if len(result.IPList) == 0 {
+		return false
+	}
+	for _, ip := range result.IPList {
+		if _, ok := wi.IPs[ip]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// randomLabel 生成一个几乎不可能真实存在的随机标签
+func randomLabel(length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// detectWildcard 在指定层级下解析若干随机标签，记录泛解析的IP与CNAME
+// 检测结果按层级缓存，不存在泛解析时返回nil
+func (ss *SubdomainScanner) detectWildcard(domain string) *wildcardInfo {
+	domain = strings.ToLower(domain)
+
+	ss.wildcardMu.Lock()
+	if info, ok := ss.wildcards[domain]; ok {
+		ss.wildcardMu.Unlock()
+		return info
+	}
+	ss.wildcardMu.Unlock()
+
+	info := &wildcardInfo{
+		IPs:    make(map[string]struct{}),
+		CNAMEs: make(map[string]struct{}),
+	}
+	for i := 0; i < ss.WildcardTests; i++ {
+		result := ss.dnsLookup(fmt.Sprintf("%s.%s", randomLabel(16), domain))
+		if result == nil {
+			continue
+		}
+		for _, ip := range result.IPList {
+			info.IPs[ip] = struct{}{}
+		}
+		for _, cname := range result.CNAMEs {
+			info.CNAMEs[strings.ToLower(cname)] = struct{}{}
+		}
+	}
+	if len(info.IPs) == 0 && len(info.CNAMEs) == 0 {
+		info = nil
+	}
+
+	ss.wildcardMu.Lock()
+	defer ss.wildcardMu.Unlock()
+	if cached, ok := ss.wildcards[domain]; ok {
+		return cached
+	}
+	ss.wildcards[domain] = info
+	return info
+}
+
+// isWildcard 判断结果是否为其所在层级的泛解析应答
+func (ss *SubdomainScanner) isWildcard(result *SubdomainResult) bool {
+	if ss.WildcardTests <= 0 {
+		return false
+	}
+
+	parent := parentDomain(result.Subdomain)
+	if parent == "" {
+		return false
+	}
+
+	info := ss.detectWildcard(parent)
+	return info != nil && info.matches(result)
+}
+
+// parentDomain 返回去掉最左侧标签后的上级域名
+func parentDomain(name string) string {
+	if idx := strings.Index(name, "."); idx >= 0 {
+		return name[idx+1:]
+	}
+	return ""
+}
+
+// sortedKeys 返回集合中排序后的键
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,150 @@
+package subdomain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// cnameRecord 构造CNAME记录
+func cnameRecord(name, target string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(name), Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   &dnsmessage.CNAMEResource{CNAME: mustName(target)},
+	}
+}
+
+// newWildcardStub 模拟三个区域：
+// ipwild.test 的任意名称解析到192.0.2.10与192.0.2.11，www单独解析到198.51.100.5
+// cnwild.test 的任意名称CNAME到lb.cdn.test，api单独CNAME到api.cdn.test
+// nowild.test 只有www存在
+func newWildcardStub(t *testing.T) *dnsStub {
+	return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		name := strings.TrimSuffix(q.Name.String(), ".")
+		if q.Type != dnsmessage.TypeA {
+			return answerRCode(dnsmessage.RCodeSuccess)
+		}
+
+		answers := make([]dnsmessage.Resource, 0)
+		switch {
+		case name == "www.ipwild.test":
+			answers = append(answers, aRecord(name, "198.51.100.5"))
+		case strings.HasSuffix(name, ".ipwild.test"):
+			answers = append(answers, aRecord(name, "192.0.2.10"), aRecord(name, "192.0.2.11"))
+		case name == "api.cnwild.test":
+			answers = append(answers, cnameRecord(name, "api.cdn.test"), aRecord("api.cdn.test", "203.0.113.99"))
+		case strings.HasSuffix(name, ".cnwild.test"):
+			answers = append(answers, cnameRecord(name, "lb.cdn.test"), aRecord("lb.cdn.test", "203.0.113.7"))
+		case name == "www.nowild.test":
+			answers = append(answers, aRecord(name, "198.51.100.9"))
+		default:
+			return answerRCode(dnsmessage.RCodeNameError)
+		}
+		return []dnsmessage.Message{{Answers: answers}}
+	})
+}
+
+func TestWildcardInfoMatches(t *testing.T) {
+	info := &wildcardInfo{
+		IPs:    map[string]struct{}{"192.0.2.10": {}, "192.0.2.11": {}},
+		CNAMEs: map[string]struct{}{"lb.cdn.test": {}},
+	}
+
+	tests := []struct {
+		name   string
+		result SubdomainResult
+		want   bool
+	}{
+		{"all ips in set", SubdomainResult{IPList: []string{"192.0.2.11", "192.0.2.10"}}, true},
+		{"subset of ips", SubdomainResult{IPList: []string{"192.0.2.10"}}, true},
+		{"one ip outside", SubdomainResult{IPList: []string{"192.0.2.10", "198.51.100.5"}}, false},
+		{"no ips", SubdomainResult{}, false},
+		{"wildcard cname", SubdomainResult{CNAMEs: []string{"LB.cdn.test"}, IPList: []string{"203.0.113.50"}}, true},
+		{"wildcard cname later in chain", SubdomainResult{CNAMEs: []string{"api.cdn.test", "lb.cdn.test"}, IPList: []string{"203.0.113.99"}}, false},
+		{"other cname on wildcard ip", SubdomainResult{CNAMEs: []string{"api.cdn.test"}, IPList: []string{"192.0.2.10"}}, true},
+	}
+	for _, tt := range tests {
+		if got := info.matches(&tt.result); got != tt.want {
+			t.Errorf("%s: matches(%+v) = %v, want %v", tt.name, tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestDetectWildcard(t *testing.T) {
+	stub := newWildcardStub(t)
+	ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+	ss.SetWildcardTests(3)
+
+	info := ss.detectWildcard("IPWild.test")
+	if info == nil {
+		t.Fatal("detectWildcard(ipwild.test) = nil, want wildcard")
+	}
+	if got := strings.Join(info.ipList(), ","); got != "192.0.2.10,192.0.2.11" {
+		t.Errorf("ipList() = %s, want 192.0.2.10,192.0.2.11", got)
+	}
+
+	info = ss.detectWildcard("cnwild.test")
+	if info == nil || strings.Join(info.cnameList(), ",") != "lb.cdn.test" {
+		t.Fatalf("detectWildcard(cnwild.test) = %+v, want CNAME lb.cdn.test", info)
+	}
+
+	if info := ss.detectWildcard("nowild.test"); info != nil {
+		t.Errorf("detectWildcard(nowild.test) = %+v, want nil", info)
+	}
+
+	// 每个层级只探测一次，包括不存在泛解析的层级
+	before := len(stub.Queries())
+	ss.detectWildcard("ipwild.test")
+	ss.detectWildcard("nowild.test")
+	if after := len(stub.Queries()); after != before {
+		t.Errorf("cached detectWildcard sent %d more queries", after-before)
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	stub := newWildcardStub(t)
+	ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+	ss.SetWildcardTests(3)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"random.ipwild.test", true},
+		{"www.ipwild.test", false},
+		{"random.cnwild.test", true},
+		{"api.cnwild.test", false},
+		{"www.nowild.test", false},
+	}
+	for _, tt := range tests {
+		result := ss.dnsLookup(tt.name)
+		if result == nil {
+			t.Fatalf("dnsLookup(%s) = nil", tt.name)
+		}
+		if got := ss.isWildcard(result); got != tt.want {
+			t.Errorf("isWildcard(%s %v %v) = %v, want %v", tt.name, result.CNAMEs, result.IPList, got, tt.want)
+		}
+	}
+
+	// 关闭检测时不做判断
+	ss.SetWildcardTests(0)
+	if ss.isWildcard(ss.dnsLookup("random.ipwild.test")) {
+		t.Error("isWildcard with WildcardTests 0 = true, want false")
+	}
+}
+
+// TestScanSkipsWildcardResults 爆破结果中与泛解析应答一致的名称被丢弃，真实主机保留
+func TestScanSkipsWildcardResults(t *testing.T) {
+	stub := newWildcardStub(t)
+	ss := newZoneScanner(stubResolver(time.Second, 0, stub))
+	ss.Domain = "ipwild.test"
+	ss.SetConcurrent(4)
+	ss.SetWordlist([]string{"www", "mail", "dev"})
+
+	results := ss.Scan()
+	if len(results) != 1 || results[0].Subdomain != "www.ipwild.test" {
+		t.Errorf("Scan() = %+v, want only www.ipwild.test", results)
+	}
+}
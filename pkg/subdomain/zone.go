@@ -0,0 +1,246 @@
+package subdomain
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// typeNSEC NSEC记录类型，dnsmessage未内置
+const typeNSEC dnsmessage.Type = 47
+
+// maxNSECSteps NSEC遍历的最大步数，防止异常区域导致死循环
+const maxNSECSteps = 10000
+
+// zoneServers 返回目标域名权威服务器的地址列表
+// 设置了ZoneServers时直接使用，否则查询NS记录并解析其地址
+func (ss *SubdomainScanner) zoneServers() []string {
+	if len(ss.ZoneServers) > 0 {
+		return ss.ZoneServers
+	}
+
+	answers, err := ss.Resolver.Lookup(ss.Domain, dnsmessage.TypeNS)
+	if err != nil {
+		return nil
+	}
+
+	servers := make([]string, 0)
+	for _, answer := range answers {
+		ns := trimDot(answer.Body.(*dnsmessage.NSResource).NS.String())
+		ips, _, err := ss.Resolver.LookupHost(ns)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			servers = append(servers, net.JoinHostPort(ip, "53"))
+		}
+	}
+	return servers
+}
+
+// enumerateZone 依次尝试对各权威服务器进行区域传送(AXFR)和NSEC遍历
+// 返回发现的名称及其来源，AXFR中出现的A/AAAA记录一并返回
+func (ss *SubdomainScanner) enumerateZone() (map[string]string, map[string][]string) {
+	sources := make(map[string]string)
+	zoneIPs := make(map[string][]string)
+
+	servers := ss.zoneServers()
+	if len(servers) == 0 {
+		ss.Logger.Warnning(fmt.Sprintf("No nameservers found for %s", ss.Domain))
+		return sources, zoneIPs
+	}
+
+	for _, server := range servers {
+		names, err := ss.transferZone(server, zoneIPs)
+		if err != nil {
+			ss.Logger.Info(fmt.Sprintf("AXFR against %s failed: %v", server, err))
+			continue
+		}
+		ss.Logger.Success(fmt.Sprintf("AXFR against %s succeeded (%d names)", server, len(names)))
+		for _, name := range names {
+			sources[name] = SourceAXFR
+		}
+	}
+
+	names, err := ss.walkNSEC(servers)
+	if err != nil {
+		ss.Logger.Info(fmt.Sprintf("NSEC walking failed: %v", err))
+	} else if len(names) > 0 {
+		ss.Logger.Success(fmt.Sprintf("NSEC walking found %d names", len(names)))
+	}
+	for _, name := range names {
+		if _, ok := sources[name]; !ok {
+			sources[name] = SourceNSEC
+		}
+	}
+
+	return sources, zoneIPs
+}
+
+// transferZone 对单个权威服务器发起AXFR请求，返回区域内的子域名
+func (ss *SubdomainScanner) transferZone(server string, zoneIPs map[string][]string) ([]string, error) {
+	qname, err := dnsmessage.NewName(fqdn(ss.Domain))
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain name %q: %v", ss.Domain, err)
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildQuery(id, qname, dnsmessage.TypeAXFR, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", server, ss.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %v", server, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(ss.Timeout))
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, fmt.Errorf("failed to send AXFR query: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	soaCount := 0
+	// 区域数据以SOA记录开始并以SOA记录结束，可能分布在多个报文中
+	for soaCount < 2 {
+		conn.SetDeadline(time.Now().Add(ss.Timeout))
+		raw, err := readTCPMessage(conn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AXFR answer: %v", err)
+		}
+
+		msg := new(dnsmessage.Message)
+		if err := msg.Unpack(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse AXFR answer: %v", err)
+		}
+		if msg.ID != id {
+			return nil, fmt.Errorf("mismatched AXFR answer id")
+		}
+		if msg.RCode != dnsmessage.RCodeSuccess {
+			return nil, fmt.Errorf("server answered %s", msg.RCode)
+		}
+		if len(msg.Answers) == 0 {
+			return nil, fmt.Errorf("empty AXFR answer")
+		}
+
+		for _, answer := range msg.Answers {
+			if answer.Header.Type == dnsmessage.TypeSOA {
+				soaCount++
+				continue
+			}
+
+			name := strings.ToLower(trimDot(answer.Header.Name.String()))
+			if !ss.inScope(name) {
+				continue
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				zoneIPs[name] = append(zoneIPs[name], net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				zoneIPs[name] = append(zoneIPs[name], net.IP(body.AAAA[:]).String())
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// walkNSEC 沿NSEC链遍历区域内的所有名称
+// 从区域顶点开始查询NSEC记录，依次跳转到下一个名称，直到回到顶点
+func (ss *SubdomainScanner) walkNSEC(servers []string) ([]string, error) {
+	resolver := NewResolver(servers)
+	resolver.Timeout = ss.Timeout
+	resolver.Retries = ss.Resolver.Retries
+	resolver.DNSSEC = true
+
+	apex := strings.ToLower(ss.Domain)
+	current := apex
+	seen := map[string]bool{apex: true}
+	names := make([]string, 0)
+
+	for step := 0; step < maxNSECSteps; step++ {
+		answers, err := resolver.Lookup(current, typeNSEC)
+		if err != nil {
+			if step == 0 {
+				return nil, fmt.Errorf("no NSEC records for %s: %v", apex, err)
+			}
+			return names, nil
+		}
+
+		next := ""
+		for _, answer := range answers {
+			body, ok := answer.Body.(*dnsmessage.UnknownResource)
+			if !ok || !strings.EqualFold(trimDot(answer.Header.Name.String()), current) {
+				continue
+			}
+			if name, ok := parseNSECNext(body.Data); ok {
+				next = strings.ToLower(name)
+				break
+			}
+		}
+
+		// 回到顶点或遇到已访问名称时遍历结束，最小覆盖NSEC(白谎言)会产生\000前缀的伪名称
+		if next == "" || seen[next] || strings.HasPrefix(next, "\\000") || !ss.inScope(next) {
+			return names, nil
+		}
+		seen[next] = true
+		if !strings.HasPrefix(next, "*.") {
+			names = append(names, next)
+		}
+		current = next
+	}
+
+	return names, nil
+}
+
+// parseNSECNext 解析NSEC记录中未压缩的下一个名称 (RFC 4034)
+func parseNSECNext(data []byte) (string, bool) {
+	labels := make([]string, 0)
+	for off := 0; off < len(data); {
+		length := int(data[off])
+		off++
+		if length == 0 {
+			return strings.Join(labels, "."), len(labels) > 0
+		}
+		if length > 63 || off+length > len(data) {
+			return "", false
+		}
+		labels = append(labels, escapeLabel(data[off:off+length]))
+		off += length
+	}
+	return "", false
+}
+
+// escapeLabel 按照主文件格式转义标签中的不可打印字符
+func escapeLabel(label []byte) string {
+	var sb strings.Builder
+	for _, c := range label {
+		switch {
+		case c == '.' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < '!' || c > '~':
+			fmt.Fprintf(&sb, "\\%03d", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// inScope 判断名称是否为目标域名的子域名
+func (ss *SubdomainScanner) inScope(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(ss.Domain))
+}
@@ -0,0 +1,179 @@
+package subdomain
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaung/nox/pkg/utils"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// soaRecord 构造SOA记录
+func soaRecord(zone string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(zone), Class: dnsmessage.ClassINET, TTL: 60},
+		Body: &dnsmessage.SOAResource{
+			NS:     mustName("ns1." + zone),
+			MBox:   mustName("hostmaster." + zone),
+			Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, MinTTL: 60,
+		},
+	}
+}
+
+// nsecRecord 构造指向next的NSEC记录，类型位图只包含A记录
+func nsecRecord(name, next string) dnsmessage.Resource {
+	data := make([]byte, 0)
+	for _, label := range strings.Split(strings.TrimSuffix(next, "."), ".") {
+		data = append(data, byte(len(label)))
+		data = append(data, label...)
+	}
+	data = append(data, 0, 0, 1, 0x40)
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: mustName(name), Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   &dnsmessage.UnknownResource{Type: typeNSEC, Data: data},
+	}
+}
+
+// nsecChain 区域example.test的NSEC链，最后一个名称指回顶点
+var nsecChain = map[string]string{
+	"example.test.":     "api.example.test",
+	"api.example.test.": "www.example.test",
+	"www.example.test.": "example.test",
+}
+
+// newZoneStub 启动example.test的权威服务器
+// AXFR分三个报文返回区域数据，其中包含区域外的名称，NSEC查询按nsecChain应答
+func newZoneStub(t *testing.T, axfr bool) *dnsStub {
+	return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		switch {
+		case q.Type == dnsmessage.TypeAXFR && tcp && axfr:
+			return []dnsmessage.Message{
+				{Answers: []dnsmessage.Resource{
+					soaRecord("example.test"),
+					aRecord("www.example.test", "192.0.2.1"),
+					aRecord("mail.example.test", "192.0.2.25"),
+				}},
+				{Answers: []dnsmessage.Resource{
+					aRecord("internal.example.test", "10.0.0.5"),
+					aRecord("internal.example.test", "10.0.0.6"),
+					aRecord("other.invalid", "192.0.2.99"),
+				}},
+				{Answers: []dnsmessage.Resource{soaRecord("example.test")}},
+			}
+		case q.Type == typeNSEC:
+			if next, ok := nsecChain[q.Name.String()]; ok {
+				return []dnsmessage.Message{{Answers: []dnsmessage.Resource{nsecRecord(q.Name.String(), next)}}}
+			}
+			return answerRCode(dnsmessage.RCodeNameError)
+		}
+		return answerRCode(dnsmessage.RCodeRefused)
+	})
+}
+
+// newPublicStub 启动递归服务器，只能公开解析www与api，其余名称不存在
+func newPublicStub(t *testing.T) *dnsStub {
+	public := map[string]string{
+		"www.example.test.": "198.51.100.1",
+		"api.example.test.": "198.51.100.2",
+	}
+	return newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		ip, ok := public[q.Name.String()]
+		switch {
+		case !ok:
+			return answerRCode(dnsmessage.RCodeNameError)
+		case q.Type == dnsmessage.TypeA:
+			return answerA(q, ip)
+		}
+		return answerRCode(dnsmessage.RCodeSuccess)
+	})
+}
+
+// newZoneScanner 创建使用测试服务器且不输出日志的扫描器
+func newZoneScanner(resolver *Resolver) *SubdomainScanner {
+	ss := NewSubdomainScanner("example.test")
+	ss.Timeout = time.Second
+	ss.Resolver = resolver
+	ss.Logger = utils.NewWithWriter(io.Discard)
+	return ss
+}
+
+func TestTransferZone(t *testing.T) {
+	zone := newZoneStub(t, true)
+	ss := newZoneScanner(stubResolver(time.Second, 0, zone))
+
+	zoneIPs := make(map[string][]string)
+	names, err := ss.transferZone(zone.Addr, zoneIPs)
+	if err != nil {
+		t.Fatalf("transferZone: %v", err)
+	}
+	if want := []string{"www.example.test", "mail.example.test", "internal.example.test"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	wantIPs := map[string][]string{
+		"www.example.test":      {"192.0.2.1"},
+		"mail.example.test":     {"192.0.2.25"},
+		"internal.example.test": {"10.0.0.5", "10.0.0.6"},
+	}
+	if !reflect.DeepEqual(zoneIPs, wantIPs) {
+		t.Errorf("zoneIPs = %v, want %v", zoneIPs, wantIPs)
+	}
+
+	refused := newZoneStub(t, false)
+	if _, err := ss.transferZone(refused.Addr, make(map[string][]string)); err == nil {
+		t.Error("transferZone on a refusing server succeeded, want error")
+	}
+}
+
+func TestWalkNSEC(t *testing.T) {
+	zone := newZoneStub(t, false)
+	ss := newZoneScanner(stubResolver(time.Second, 0, zone))
+
+	names, err := ss.walkNSEC([]string{zone.Addr})
+	if err != nil {
+		t.Fatalf("walkNSEC: %v", err)
+	}
+	if want := []string{"api.example.test", "www.example.test"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	// 没有NSEC记录的区域
+	unsigned := newDNSStub(t, func(q dnsmessage.Question, tcp bool) []dnsmessage.Message {
+		return answerRCode(dnsmessage.RCodeSuccess)
+	})
+	if _, err := ss.walkNSEC([]string{unsigned.Addr}); err == nil {
+		t.Error("walkNSEC on an unsigned zone succeeded, want error")
+	}
+}
+
+// TestScanZoneWalk 区域数据中的名称带有来源标记，无法公开解析的名称使用区域数据中的地址
+func TestScanZoneWalk(t *testing.T) {
+	zone := newZoneStub(t, true)
+	ss := newZoneScanner(stubResolver(time.Second, 0, newPublicStub(t)))
+	ss.ZoneServers = []string{zone.Addr}
+	ss.SetZoneWalk(true)
+
+	type found struct {
+		Source string
+		IPs    []string
+	}
+	got := make(map[string]found)
+	for _, result := range ss.Scan() {
+		ips := append([]string(nil), result.IPList...)
+		sort.Strings(ips)
+		got[result.Subdomain] = found{Source: result.Source, IPs: ips}
+	}
+
+	want := map[string]found{
+		"www.example.test":      {Source: SourceAXFR, IPs: []string{"198.51.100.1"}},
+		"mail.example.test":     {Source: SourceAXFR, IPs: []string{"192.0.2.25"}},
+		"internal.example.test": {Source: SourceAXFR, IPs: []string{"10.0.0.5", "10.0.0.6"}},
+		"api.example.test":      {Source: SourceNSEC, IPs: []string{"198.51.100.2"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %+v, want %+v", got, want)
+	}
+}
@@ -2,33 +2,51 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/fatih/color"
 )
 
-type Logger struct{}
+type Logger struct {
+	out io.Writer // 日志输出位置，为空时输出到标准输出
+}
 
 func New() *Logger {
 	return &Logger{}
 }
 
+// NewWithWriter 创建输出到指定位置的日志记录器
+// 结果需要输出到标准输出时(例如JSON行格式)，可以将日志输出到标准错误
+func NewWithWriter(out io.Writer) *Logger {
+	return &Logger{out: out}
+}
+
+// writer 返回日志输出位置
+func (l *Logger) writer() io.Writer {
+	if l.out == nil {
+		return os.Stdout
+	}
+	return l.out
+}
+
 func (l *Logger) Info(message string) {
 	highlight := color.New(color.FgBlue).SprintFunc()
 	reset := color.New(color.FgWhite).SprintFunc()
-	fmt.Println(highlight("[*]"), reset(message))
+	fmt.Fprintln(l.writer(), highlight("[*]"), reset(message))
 }
 
 func (l *Logger) Warnning(message string) {
 	highlight := color.New(color.FgYellow).SprintFunc()
-	fmt.Println(highlight("[!]"), highlight(message))
+	fmt.Fprintln(l.writer(), highlight("[!]"), highlight(message))
 }
 
 func (l *Logger) LoggerError(message string) {
 	highlight := color.New(color.FgRed).SprintFunc()
-	fmt.Println(highlight("[-]"), highlight(message))
+	fmt.Fprintln(l.writer(), highlight("[-]"), highlight(message))
 }
 
 func (l *Logger) Success(message string) {
 	highlight := color.New(color.FgGreen).SprintFunc()
-	fmt.Println(highlight("[+]"), highlight(message))
+	fmt.Fprintln(l.writer(), highlight("[+]"), highlight(message))
 }
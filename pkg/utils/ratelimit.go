@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 简单的固定间隔限速器，可在多个goroutine中共享
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// NewRateLimiter 创建每两次操作之间至少间隔interval的限速器
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// Wait 阻塞直到可以进行下一次操作
+func (rl *RateLimiter) Wait() {
+	rl.mu.Lock()
+	now := time.Now()
+	next := rl.last.Add(rl.interval)
+	if next.Before(now) {
+		next = now
+	}
+	rl.last = next
+	rl.mu.Unlock()
+
+	time.Sleep(time.Until(next))
+}
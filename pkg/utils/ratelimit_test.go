@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterSpacing 并发调用Wait时每次操作之间至少间隔interval
+func TestRateLimiterSpacing(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	rl := NewRateLimiter(interval)
+
+	var mu sync.Mutex
+	times := make([]time.Time, 0)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rl.Wait()
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	first, last := times[0], times[0]
+	for _, at := range times {
+		if at.Before(first) {
+			first = at
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	// 5次操作至少跨越4个间隔，留少量余量应对计时误差
+	if elapsed := last.Sub(first); elapsed < 4*interval-5*time.Millisecond {
+		t.Errorf("5 waits took %s, want at least %s", elapsed, 4*interval)
+	}
+}
@@ -0,0 +1,137 @@
+package waf
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed cdn.txt
+var cdnData []byte
+
+// CDNRanges CDN与云WAF服务商的网段
+type CDNRanges struct {
+	entries []cdnEntry
+}
+
+// cdnEntry 服务商的一个网段
+type cdnEntry struct {
+	network  *net.IPNet
+	provider string
+}
+
+// CDNHost 主机的CDN检测结果
+type CDNHost struct {
+	Host      string   // 主机名或IP
+	IPs       []string // 解析到的IP
+	Providers []string // 命中的CDN服务商
+	Fronted   bool     // 所有IP均属于CDN网段，此时端口扫描得到的是CDN节点而非源站
+}
+
+// DefaultCDNRanges 返回内置的CDN网段
+// 内置网段随程序一起编译，无法解析时说明网段文件有误，直接panic而不是静默关闭检测
+func DefaultCDNRanges() *CDNRanges {
+	ranges, err := parseCDNRanges(bytes.NewReader(cdnData))
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded CDN ranges: %v", err))
+	}
+	if len(ranges.entries) == 0 {
+		panic("no embedded CDN ranges")
+	}
+	return ranges
+}
+
+// LoadCDNRanges 读取CDN网段文件，每行为 网段 服务商名称，#开头的行为注释
+func LoadCDNRanges(path string) (*CDNRanges, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CDN ranges: %v", err)
+	}
+	defer file.Close()
+
+	ranges, err := parseCDNRanges(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges.entries) == 0 {
+		return nil, fmt.Errorf("no CDN ranges found in %s", path)
+	}
+	return ranges, nil
+}
+
+// parseCDNRanges 解析CDN网段，空行与#注释之外的行必须是 网段 服务商名称
+func parseCDNRanges(reader io.Reader) (*CDNRanges, error) {
+	ranges := &CDNRanges{entries: make([]cdnEntry, 0)}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: missing CDN provider for %s", line, fields[0])
+		}
+		_, network, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid CDN range %q: %v", line, fields[0], err)
+		}
+		ranges.entries = append(ranges.entries, cdnEntry{network: network, provider: strings.Join(fields[1:], " ")})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CDN ranges: %v", err)
+	}
+	return ranges, nil
+}
+
+// Merge 合并另一组网段
+func (c *CDNRanges) Merge(other *CDNRanges) {
+	c.entries = append(c.entries, other.entries...)
+}
+
+// Lookup 返回IP所属的CDN服务商，不属于任何CDN时返回空字符串
+func (c *CDNRanges) Lookup(ip net.IP) string {
+	for _, entry := range c.entries {
+		if entry.network.Contains(ip) {
+			return entry.provider
+		}
+	}
+	return ""
+}
+
+// CheckHost 解析主机并判断其IP是否属于CDN网段
+func (c *CDNRanges) CheckHost(host string) (*CDNHost, error) {
+	result := &CDNHost{Host: host, IPs: make([]string, 0), Providers: make([]string, 0)}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := net.LookupIP(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", host, err)
+		}
+		ips = addrs
+	}
+
+	fronted := 0
+	seen := make(map[string]bool)
+	for _, ip := range ips {
+		result.IPs = append(result.IPs, ip.String())
+		provider := c.Lookup(ip)
+		if provider == "" {
+			continue
+		}
+		fronted++
+		if !seen[provider] {
+			seen[provider] = true
+			result.Providers = append(result.Providers, provider)
+		}
+	}
+	sort.Strings(result.Providers)
+	result.Fronted = len(ips) > 0 && fronted == len(ips)
+	return result, nil
+}
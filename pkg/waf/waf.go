@@ -0,0 +1,432 @@
+package waf
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/seaung/nox/pkg/utils"
+)
+
+// 特征类型
+const (
+	TypeWAF = "waf" // Web应用防火墙
+	TypeCDN = "cdn" // CDN或带防护能力的反向代理
+)
+
+// maxBodySize 读取响应内容的最大长度
+const maxBodySize = 1 << 20
+
+// userAgent 探测请求使用的User-Agent，避免因默认UA被拦截而误判
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+//go:embed signatures.json
+var signatureData []byte
+
+// blockPattern 通用拦截页面特征
+var blockPattern = regexp.MustCompile(`(?i)access denied|request (?:was )?(?:blocked|rejected)|web application firewall|security polic(?:y|ies)|malicious|not acceptable|拦截|非法请求|安全威胁|防火墙`)
+
+// blockStatuses 恶意请求被拦截时常见的状态码
+var blockStatuses = []int{400, 403, 405, 406, 418, 429, 444, 451, 493, 501, 503, 999}
+
+// Signature WAF/CDN厂商特征
+type Signature struct {
+	Vendor  string            `json:"vendor"`  // 厂商名称
+	Type    string            `json:"type"`    // 类型 waf/cdn
+	Headers map[string]string `json:"headers"` // 响应头名称与取值正则，正则为空时只要求存在该响应头
+	Cookies []string          `json:"cookies"` // Cookie名称正则
+	Body    []string          `json:"body"`    // 拦截页面正则
+	Status  []int             `json:"status"`  // 拦截时使用的状态码，仅在命中其他特征时作为佐证
+
+	headers map[string]*regexp.Regexp // 编译后的响应头正则
+	cookies []*regexp.Regexp          // 编译后的Cookie正则
+	body    []*regexp.Regexp          // 编译后的拦截页面正则
+}
+
+// Probe 探测请求，载荷以查询参数的形式附加到目标URL
+type Probe struct {
+	Name    string // 名称
+	Payload string // 载荷
+}
+
+// ProbeResult 单个探测请求的结果
+type ProbeResult struct {
+	Name       string // 探测名称
+	URL        string // 请求地址
+	StatusCode int    // 状态码，请求失败时为0
+	Blocked    bool   // 是否被拦截
+	Reason     string // 判定为拦截的依据
+	Error      string // 请求失败且不能判定为拦截时的错误，例如超时、DNS或TLS错误
+}
+
+// VendorMatch 识别到的厂商
+type VendorMatch struct {
+	Vendor   string   // 厂商名称
+	Type     string   // 类型 waf/cdn
+	Evidence []string // 命中的特征
+}
+
+// WAFResult WAF检测结果
+type WAFResult struct {
+	URL      string        // 目标URL
+	Detected bool          // 是否存在WAF
+	Vendor   string        // 识别到的WAF厂商，存在拦截但无法识别厂商时为unknown
+	Blocked  bool          // 恶意请求是否被拦截
+	Vendors  []VendorMatch // 命中特征的全部WAF/CDN厂商
+	Probes   []ProbeResult // 各探测请求的结果
+	CDN      *CDNHost      // 目标主机的CDN检测结果
+}
+
+// WAFDetector WAF与CDN检测器
+type WAFDetector struct {
+	Target     string        // 目标URL
+	Timeout    time.Duration // 请求超时时间
+	Signatures []Signature   // 厂商特征
+	Probes     []Probe       // 恶意探测请求
+	CDNRanges  *CDNRanges    // CDN网段
+	Logger     *utils.Logger // 日志记录器
+}
+
+// response 检测使用的HTTP响应
+type response struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// DefaultProbes 返回内置的恶意探测请求
+func DefaultProbes() []Probe {
+	return []Probe{
+		{Name: "sqli", Payload: "1' AND 1=1 UNION SELECT NULL,table_name FROM information_schema.tables-- -"},
+		{Name: "xss", Payload: "<script>alert(document.cookie)</script>"},
+		{Name: "traversal", Payload: "../../../../../../etc/passwd"},
+		{Name: "cmdi", Payload: ";cat /etc/passwd | nc 127.0.0.1 4444"},
+	}
+}
+
+// NewWAFDetector 创建一个新的WAF检测器实例
+func NewWAFDetector(target string) *WAFDetector {
+	return &WAFDetector{
+		Target:     target,
+		Timeout:    time.Second * 10,
+		Signatures: DefaultSignatures(),
+		Probes:     DefaultProbes(),
+		CDNRanges:  DefaultCDNRanges(),
+		Logger:     utils.New(),
+	}
+}
+
+// SetTimeout 设置请求超时时间
+func (wd *WAFDetector) SetTimeout(timeout time.Duration) {
+	wd.Timeout = timeout
+}
+
+// SetSignatures 设置厂商特征
+func (wd *WAFDetector) SetSignatures(signatures []Signature) {
+	wd.Signatures = signatures
+}
+
+// SetCDNRanges 设置CDN网段
+func (wd *WAFDetector) SetCDNRanges(ranges *CDNRanges) {
+	wd.CDNRanges = ranges
+}
+
+// DefaultSignatures 返回内置的WAF/CDN厂商特征
+// 内置特征随程序一起编译，无法解析时说明特征文件有误，直接panic而不是静默关闭检测
+func DefaultSignatures() []Signature {
+	signatures, err := parseSignatures(signatureData)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded WAF signatures: %v", err))
+	}
+	return signatures
+}
+
+// LoadSignatures 从JSON文件中读取WAF/CDN厂商特征
+func LoadSignatures(path string) ([]Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WAF signatures: %v", err)
+	}
+	return parseSignatures(data)
+}
+
+// parseSignatures 解析并编译厂商特征
+func parseSignatures(data []byte) ([]Signature, error) {
+	signatures := make([]Signature, 0)
+	if err := json.Unmarshal(data, &signatures); err != nil {
+		return nil, fmt.Errorf("failed to parse WAF signatures: %v", err)
+	}
+	for i := range signatures {
+		if err := signatures[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return signatures, nil
+}
+
+// compile 编译特征中的正则表达式
+func (sig *Signature) compile() error {
+	if sig.Type == "" {
+		sig.Type = TypeWAF
+	}
+
+	sig.headers = make(map[string]*regexp.Regexp)
+	for name, pattern := range sig.Headers {
+		var re *regexp.Regexp
+		if pattern != "" {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("signature %s: invalid header regex %q: %v", sig.Vendor, pattern, err)
+			}
+		}
+		sig.headers[http.CanonicalHeaderKey(name)] = re
+	}
+
+	var err error
+	if sig.cookies, err = compileAll(sig.Vendor, sig.Cookies); err != nil {
+		return err
+	}
+	if sig.body, err = compileAll(sig.Vendor, sig.Body); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compileAll 编译一组正则表达式，(?s)使拦截页面特征可以跨行匹配
+func compileAll(vendor string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?s)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("signature %s: invalid regex %q: %v", vendor, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Detect 发送正常请求与恶意请求并比较响应，识别WAF厂商与CDN
+func (wd *WAFDetector) Detect() (*WAFResult, error) {
+	target, err := url.Parse(wd.Target)
+	if err != nil || target.Host == "" {
+		return nil, fmt.Errorf("invalid target URL: %s", wd.Target)
+	}
+
+	// 不跟随跳转，拦截时的跳转同样是判断依据
+	client := &http.Client{
+		Timeout: wd.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	baseline, err := wd.fetch(client, wd.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WAFResult{
+		URL:     wd.Target,
+		Vendors: make([]VendorMatch, 0),
+		Probes:  make([]ProbeResult, 0, len(wd.Probes)),
+	}
+	responses := []*response{baseline}
+	blockedStatuses := make([]int, 0)
+	for _, probe := range wd.Probes {
+		probeURL := withPayload(target, probe.Payload)
+		resp, err := wd.fetch(client, probeURL)
+		probeResult := ProbeResult{Name: probe.Name, URL: probeURL}
+		switch {
+		case connectionDropped(err):
+			// 正常请求成功而恶意请求连接被重置，通常是WAF直接断开连接
+			probeResult.Blocked = true
+			probeResult.Reason = fmt.Sprintf("connection dropped: %v", err)
+		case err != nil:
+			// 超时、DNS或TLS错误不能说明请求被拦截
+			probeResult.Error = err.Error()
+		default:
+			responses = append(responses, resp)
+			probeResult.StatusCode = resp.StatusCode
+			probeResult.Reason = blockReason(baseline, resp)
+			probeResult.Blocked = probeResult.Reason != ""
+			if probeResult.Blocked {
+				blockedStatuses = append(blockedStatuses, resp.StatusCode)
+			}
+		}
+
+		switch {
+		case probeResult.Blocked:
+			result.Blocked = true
+			wd.Logger.Warnning(fmt.Sprintf("Probe %s blocked: %s", probe.Name, probeResult.Reason))
+		case probeResult.Error != "":
+			wd.Logger.Warnning(fmt.Sprintf("Probe %s failed: %s", probe.Name, probeResult.Error))
+		default:
+			wd.Logger.Info(fmt.Sprintf("Probe %s passed (status %d)", probe.Name, probeResult.StatusCode))
+		}
+		result.Probes = append(result.Probes, probeResult)
+	}
+
+	// 匹配厂商特征
+	for i := range wd.Signatures {
+		if match := wd.Signatures[i].match(responses, blockedStatuses); match != nil {
+			result.Vendors = append(result.Vendors, *match)
+			wd.Logger.Success(fmt.Sprintf("Detected %s: %s (%s)", match.Type, match.Vendor, strings.Join(match.Evidence, "; ")))
+		}
+	}
+	for _, match := range result.Vendors {
+		if match.Type == TypeWAF {
+			result.Vendor = match.Vendor
+			break
+		}
+	}
+	if result.Vendor == "" && result.Blocked {
+		// 存在拦截时，带防护能力的CDN视为WAF
+		if len(result.Vendors) > 0 {
+			result.Vendor = result.Vendors[0].Vendor
+		} else {
+			result.Vendor = "unknown"
+		}
+	}
+	result.Detected = result.Vendor != ""
+
+	// 判断目标主机是否位于CDN之后
+	if wd.CDNRanges != nil {
+		cdn, err := wd.CDNRanges.CheckHost(target.Hostname())
+		if err != nil {
+			wd.Logger.Warnning(fmt.Sprintf("CDN check failed: %v", err))
+		} else {
+			result.CDN = cdn
+			if len(cdn.Providers) > 0 {
+				wd.Logger.Success(fmt.Sprintf("%s is served by CDN: %s", cdn.Host, strings.Join(cdn.Providers, ", ")))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// fetch 发送请求并读取响应
+func (wd *WAFDetector) fetch(client *http.Client, target string) (*response, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return &response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}, nil
+}
+
+// match 在全部响应中匹配厂商特征，未命中时返回nil
+func (sig *Signature) match(responses []*response, blockedStatuses []int) *VendorMatch {
+	evidence := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(e string) {
+		if !seen[e] {
+			seen[e] = true
+			evidence = append(evidence, e)
+		}
+	}
+
+	for _, resp := range responses {
+		for name, re := range sig.headers {
+			for _, value := range resp.Header.Values(name) {
+				if re == nil || re.MatchString(value) {
+					add(fmt.Sprintf("header %s: %s", name, value))
+				}
+			}
+		}
+		for _, cookie := range (&http.Response{Header: resp.Header}).Cookies() {
+			for _, re := range sig.cookies {
+				if re.MatchString(cookie.Name) {
+					add("cookie " + cookie.Name)
+				}
+			}
+		}
+		for _, re := range sig.body {
+			if match := re.FindString(resp.Body); match != "" {
+				if len(match) > 80 {
+					match = match[:80]
+				}
+				add("body " + strings.Join(strings.Fields(match), " "))
+			}
+		}
+	}
+	if len(evidence) == 0 {
+		return nil
+	}
+
+	// 拦截状态码只作为佐证
+	for _, status := range blockedStatuses {
+		for _, want := range sig.Status {
+			if status == want {
+				add(fmt.Sprintf("block status %d", status))
+			}
+		}
+	}
+	sort.Strings(evidence)
+
+	return &VendorMatch{Vendor: sig.Vendor, Type: sig.Type, Evidence: evidence}
+}
+
+// blockReason 比较正常请求与恶意请求的响应，返回判定为拦截的依据
+func blockReason(baseline, resp *response) string {
+	if resp.StatusCode != baseline.StatusCode {
+		for _, status := range blockStatuses {
+			if resp.StatusCode == status {
+				return fmt.Sprintf("status %d (baseline %d)", resp.StatusCode, baseline.StatusCode)
+			}
+		}
+	}
+	if match := blockPattern.FindString(resp.Body); match != "" && !blockPattern.MatchString(baseline.Body) {
+		return fmt.Sprintf("block page keyword %q", match)
+	}
+	return ""
+}
+
+// connectionDropped 判断请求是否因服务端重置或关闭连接而失败
+func connectionDropped(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withPayload 将载荷作为查询参数附加到URL
+func withPayload(target *url.URL, payload string) string {
+	probe := *target
+	query := probe.Query()
+	query.Set("id", payload)
+	probe.RawQuery = query.Encode()
+	return probe.String()
+}
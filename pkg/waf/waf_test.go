@@ -0,0 +1,245 @@
+package waf
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaung/nox/pkg/utils"
+)
+
+// newTestDetector 创建不输出日志、不检查CDN网段的检测器
+func newTestDetector(target string) *WAFDetector {
+	wd := NewWAFDetector(target)
+	wd.SetTimeout(2 * time.Second)
+	wd.SetCDNRanges(nil)
+	wd.Logger = utils.NewWithWriter(io.Discard)
+	return wd
+}
+
+// isProbe 判断请求是否带有探测载荷
+func isProbe(r *http.Request) bool {
+	return r.URL.Query().Get("id") != ""
+}
+
+func TestEmbeddedData(t *testing.T) {
+	signatures, err := parseSignatures(signatureData)
+	if err != nil {
+		t.Fatalf("failed to parse embedded signatures: %v", err)
+	}
+	if len(signatures) == 0 {
+		t.Error("no embedded signatures")
+	}
+	for _, sig := range signatures {
+		if sig.Vendor == "" || (sig.Type != TypeWAF && sig.Type != TypeCDN) {
+			t.Errorf("invalid signature vendor %q type %q", sig.Vendor, sig.Type)
+		}
+	}
+
+	ranges, err := parseCDNRanges(strings.NewReader(string(cdnData)))
+	if err != nil {
+		t.Fatalf("failed to parse embedded CDN ranges: %v", err)
+	}
+	if len(ranges.entries) == 0 {
+		t.Error("no embedded CDN ranges")
+	}
+}
+
+func TestParseCDNRangesErrors(t *testing.T) {
+	for _, data := range []string{
+		"10.0.0.0/8",
+		"10.0.0.300/8 Broken",
+		"example.com Broken",
+	} {
+		if _, err := parseCDNRanges(strings.NewReader("# comment\n\n" + data + "\n")); err == nil {
+			t.Errorf("parseCDNRanges(%q) succeeded, want error", data)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		detected bool
+		vendor   string
+		blocked  bool
+		vendors  []string
+	}{
+		{
+			name: "vendor header and cookie",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Sucuri-ID", "15016")
+				http.SetCookie(w, &http.Cookie{Name: "incap_ses_123_456", Value: "x"})
+				w.Write([]byte("<html>welcome</html>"))
+			},
+			detected: true,
+			vendor:   "Imperva Incapsula",
+			vendors:  []string{"Imperva Incapsula", "Sucuri CloudProxy"},
+		},
+		{
+			name: "cdn without blocking",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Server", "cloudflare")
+				w.Header().Set("CF-RAY", "8a1b2c3d4e5f-LAX")
+				w.Write([]byte("<html>welcome</html>"))
+			},
+			vendors: []string{"Cloudflare"},
+		},
+		{
+			name: "blocked probe",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if isProbe(r) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("<h1>Access Denied</h1>"))
+					return
+				}
+				w.Write([]byte("<html>welcome</html>"))
+			},
+			detected: true,
+			vendor:   "unknown",
+			blocked:  true,
+		},
+		{
+			name: "blocking cdn",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Server", "cloudflare")
+				if isProbe(r) {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("Attention Required! | Cloudflare"))
+					return
+				}
+				w.Write([]byte("<html>welcome</html>"))
+			},
+			detected: true,
+			vendor:   "Cloudflare",
+			blocked:  true,
+			vendors:  []string{"Cloudflare"},
+		},
+		{
+			name: "clean site",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Server", "nginx")
+				w.Write([]byte("<html>Our security policy is described on the about page</html>"))
+			},
+		},
+		{
+			name: "connection dropped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if isProbe(r) {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err == nil {
+						conn.Close()
+					}
+					return
+				}
+				w.Write([]byte("<html>welcome</html>"))
+			},
+			detected: true,
+			vendor:   "unknown",
+			blocked:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			result, err := newTestDetector(server.URL + "/").Detect()
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if result.Detected != tt.detected || result.Vendor != tt.vendor || result.Blocked != tt.blocked {
+				t.Errorf("Detect() = detected %v vendor %q blocked %v, want %v %q %v", result.Detected, result.Vendor, result.Blocked, tt.detected, tt.vendor, tt.blocked)
+			}
+			vendors := make([]string, 0)
+			for _, match := range result.Vendors {
+				vendors = append(vendors, match.Vendor)
+			}
+			if strings.Join(vendors, ",") != strings.Join(tt.vendors, ",") {
+				t.Errorf("Vendors = %v, want %v", vendors, tt.vendors)
+			}
+			if len(result.Probes) != len(DefaultProbes()) {
+				t.Errorf("got %d probe results, want %d", len(result.Probes), len(DefaultProbes()))
+			}
+			for _, probe := range result.Probes {
+				if probe.Blocked != tt.blocked {
+					t.Errorf("probe %s blocked = %v (%s), want %v", probe.Name, probe.Blocked, probe.Reason, tt.blocked)
+				}
+			}
+		})
+	}
+}
+
+// TestDetectProbeTimeout 探测请求超时只记录为错误，不判定为拦截
+func TestDetectProbeTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isProbe(r) {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte("<html>welcome</html>"))
+	}))
+	defer server.Close()
+
+	wd := newTestDetector(server.URL + "/")
+	wd.SetTimeout(100 * time.Millisecond)
+	wd.Probes = DefaultProbes()[:1]
+	result, err := wd.Detect()
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if result.Blocked || result.Detected {
+		t.Errorf("Detect() = blocked %v detected %v, want neither", result.Blocked, result.Detected)
+	}
+	if probe := result.Probes[0]; probe.Blocked || probe.Error == "" {
+		t.Errorf("probe = %+v, want an unblocked probe with an error", probe)
+	}
+}
+
+func TestCDNRanges(t *testing.T) {
+	ranges, err := parseCDNRanges(strings.NewReader("127.0.0.0/8 Loopback CDN\n2001:db8::/32 Doc CDN\n"))
+	if err != nil {
+		t.Fatalf("parseCDNRanges: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	wd := newTestDetector(server.URL + "/")
+	wd.SetCDNRanges(ranges)
+	result, err := wd.Detect()
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if result.CDN == nil || !result.CDN.Fronted || strings.Join(result.CDN.Providers, ",") != "Loopback CDN" {
+		t.Errorf("CDN = %+v, want fronted by Loopback CDN", result.CDN)
+	}
+
+	for _, tt := range []struct {
+		host     string
+		provider string
+		fronted  bool
+	}{
+		{host: "2001:db8::1", provider: "Doc CDN", fronted: true},
+		{host: "192.0.2.1", provider: "", fronted: false},
+	} {
+		host, err := ranges.CheckHost(tt.host)
+		if err != nil {
+			t.Fatalf("CheckHost(%s): %v", tt.host, err)
+		}
+		if strings.Join(host.Providers, ",") != tt.provider || host.Fronted != tt.fronted {
+			t.Errorf("CheckHost(%s) = %+v, want provider %q fronted %v", tt.host, host, tt.provider, tt.fronted)
+		}
+	}
+
+	if provider := DefaultCDNRanges().Lookup(net.ParseIP("104.16.0.1")); provider != "Cloudflare" {
+		t.Errorf("Lookup(104.16.0.1) = %q, want Cloudflare", provider)
+	}
+}